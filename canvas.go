@@ -36,11 +36,12 @@ char *MagickGetPropertyName(char **properties, size_t index) {
 import "C"
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
-	"strconv"
 	"strings"
 	"unsafe"
 )
@@ -64,6 +65,14 @@ type Canvas struct {
 	quantumRange uint
 
 	text *TextProperties
+
+	limits *Limits
+
+	patterns  uint
+	clipPaths uint
+
+	// Latest error of a method that can't return it, see Error().
+	err error
 }
 
 func init() {
@@ -101,12 +110,16 @@ func (self *Canvas) Open(filename string) error {
 		return fmt.Errorf(`Could not open file "%s": it's a directory!`, filename)
 	}
 
+	if err = self.checkFileLimits(filename); err != nil {
+		return err
+	}
+
 	cfilename := C.CString(filename)
 	status := C.MagickReadImage(self.wand, cfilename)
 	C.free(unsafe.Pointer(cfilename))
 
 	if status == C.MagickFalse {
-		return fmt.Errorf(`Could not open image "%s": %s`, filename, self.Error())
+		return self.magickError(fmt.Sprintf(`open image "%s"`, filename))
 	}
 
 	self.filename = filename
@@ -122,7 +135,7 @@ func (self *Canvas) SetOption(key, value string) error {
 	defer C.free(unsafe.Pointer(cvalue))
 
 	if C.MagickSetOption(self.wand, ckey, cvalue) == C.MagickFalse {
-		return fmt.Errorf(`Could not set option "%s" to "%s": %s`, ckey, cvalue, self.Error())
+		return self.magickError(fmt.Sprintf(`set option "%s" to "%s"`, key, value))
 	}
 
 	return nil
@@ -133,7 +146,7 @@ func (self *Canvas) SetCaption(content string) error {
 	defer C.free(unsafe.Pointer(ccontent))
 
 	if C.MagickReadImage(self.wand, ccontent) == C.MagickFalse {
-		return fmt.Errorf(`Could not open image "%s": %s`, content, self.Error())
+		return self.magickError(fmt.Sprintf(`open image "%s"`, content))
 	}
 
 	C.MagickDrawImage(self.wand, self.drawing)
@@ -141,14 +154,20 @@ func (self *Canvas) SetCaption(content string) error {
 	return nil
 }
 
+// Draws content wrapped in a width x height box at the top-left corner of
+// the canvas, see DrawText() for finer control.
 func (self *Canvas) DrawAnnotation(content string, width, height uint) error {
-	ccontent := C.CString("caption:" + content)
-	defer C.free(unsafe.Pointer(ccontent))
+	box := &TextBox{
+		Width:  float64(width),
+		Height: float64(height),
+	}
 
-	C.DrawAnnotation(self.drawing, 20, 20, (*C.uchar)(unsafe.Pointer(ccontent)))
+	if err := self.DrawText(content, box); err != nil {
+		return err
+	}
 
 	if C.MagickDrawImage(self.wand, self.drawing) == C.MagickFalse {
-		return fmt.Errorf(`Could not draw annotation: %s`, self.Error())
+		return self.magickError("draw annotation")
 	}
 
 	return nil
@@ -156,65 +175,20 @@ func (self *Canvas) DrawAnnotation(content string, width, height uint) error {
 
 // Reads an image or image sequence from a blob.
 func (self *Canvas) OpenBlob(blob []byte, length uint) error {
-	status := C.MagickReadImageBlob(self.wand, unsafe.Pointer(&blob[0]), C.size_t(length))
-
-	if status == C.MagickFalse {
-		return fmt.Errorf(`Could not open image from blob: %s`, self.Error())
+	if length == 0 || length > uint(len(blob)) {
+		return errors.New("Could not open image from blob: invalid length.")
 	}
 
-	return nil
-}
-
-// Auto-orientates canvas based on its original image's EXIF metadata
-func (self *Canvas) AutoOrientate() error {
-
-	data := self.Metadata()
-
-	orientation, err := strconv.Atoi(data["exif:Orientation"])
-
-	if err != nil {
+	if err := self.checkBlobLimits(blob[:length], ""); err != nil {
 		return err
 	}
 
-	switch uint(orientation) {
-	case TOP_LEFT_ORIENTATION:
-		// normal
-
-	case TOP_RIGHT_ORIENTATION:
-		self.Flop()
-
-	case BOTTOM_RIGHT_ORIENTATION:
-		self.RotateCanvas(math.Pi)
-
-	case BOTTOM_LEFT_ORIENTATION:
-		self.Flip()
-
-	case LEFT_TOP_ORIENTATION:
-		self.Flip()
-		self.RotateCanvas(-math.Pi / 2)
-
-	case RIGHT_TOP_ORIENTATION:
-		self.RotateCanvas(math.Pi / 2)
-
-	case RIGHT_BOTTOM_ORIENTATION:
-		self.Flop()
-		self.RotateCanvas(-math.Pi / 2)
-
-	case LEFT_BOTTOM_ORIENTATION:
-		self.RotateCanvas(-math.Pi / 2)
-
-	default:
-		return errors.New("No orientation data found in file.")
-	}
-
-	success := C.MagickSetImageOrientation(self.wand, (C.OrientationType)(TOP_LEFT_ORIENTATION))
+	status := C.MagickReadImageBlob(self.wand, unsafe.Pointer(&blob[0]), C.size_t(length))
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not orientate photo: %s", self.Error())
+	if status == C.MagickFalse {
+		return self.magickError("open image from blob")
 	}
 
-	self.SetMetadata("exif:Orientation", (string)(TOP_LEFT_ORIENTATION))
-
 	return nil
 }
 
@@ -247,14 +221,20 @@ func (self *Canvas) Metadata() map[string]string {
 	return data
 }
 
-// Returns the latest error reported by the MagickWand API.
+// Returns the latest exception reported by the MagickWand API as a
+// *MagickError, or nil if there is none. Operations that succeed may still
+// leave a warning behind, use IsWarning() to tell them apart. Failures of
+// methods that don't return an error, such as Annotate(), are reported here
+// first.
 func (self *Canvas) Error() error {
-	var t C.ExceptionType
-	ptr := C.MagickGetException(self.wand, &t)
-	message := C.GoString(ptr)
-	C.MagickClearException(self.wand)
-	C.MagickRelinquishMemory(unsafe.Pointer(ptr))
-	return errors.New(message)
+	if err := self.err; err != nil {
+		self.err = nil
+		return err
+	}
+	if C.MagickGetExceptionType(self.wand) == C.UndefinedException {
+		return nil
+	}
+	return self.magickError("")
 }
 
 // Associates a metadata key with its value.
@@ -268,7 +248,7 @@ func (self *Canvas) SetMetadata(key string, value string) error {
 	C.free(unsafe.Pointer(cval))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set metadata: %s", self.Error())
+		return self.magickError("set metadata")
 	}
 
 	return nil
@@ -279,7 +259,7 @@ func (self *Canvas) Flop() error {
 	success := C.MagickFlopImage(self.wand)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not flop image: %s", self.Error())
+		return self.magickError("flop image")
 	}
 
 	return nil
@@ -290,7 +270,7 @@ func (self *Canvas) Flip() error {
 	success := C.MagickFlipImage(self.wand)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not flop image: %s", self.Error())
+		return self.magickError("flip image")
 	}
 
 	return nil
@@ -301,7 +281,7 @@ func (self *Canvas) SigmoidalContrast(sharpen bool, alpha float64, beta float64)
 	status := C.MagickSigmoidalContrastImage(self.wand, magickBoolean(sharpen), C.double(alpha), C.double(beta))
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not contrast image: %s", self.Error())
+		return self.magickError("contrast image")
 	}
 
 	return nil
@@ -312,7 +292,7 @@ func (self *Canvas) Contrast(sharpen bool) error {
 	status := C.MagickContrastImage(self.wand, magickBoolean(sharpen))
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not contrast image: %s", self.Error())
+		return self.magickError("contrast image")
 	}
 
 	return nil
@@ -341,46 +321,61 @@ func (self *Canvas) Clone() *Canvas {
 // Is width and height are smaller than the current image, the image
 // will be resized and cropped, if needed.
 func (self *Canvas) Thumbnail(width uint, height uint) error {
-
-	var ratio float64
-
-	// Normalizing image.
-
-	ratio = math.Min(float64(self.Width())/float64(width), float64(self.Height())/float64(height))
-
-	return self.thumbnail(width, height, ratio)
+	return self.thumbnail(width, height, math.Min)
 }
 
 // Creates a thumbnail that fits within the given dimensions
 func (self *Canvas) Fit(width uint, height uint) error {
+	return self.thumbnail(width, height, math.Max)
+}
+
+func (self *Canvas) thumbnail(width uint, height uint, normalize func(float64, float64) float64) error {
 
 	var ratio float64
 
-	// Normalizing image.
+	if self.Frames() > 1 {
+		if !self.isAnimation() {
+			// Pages, e.g. of a TIFF, may differ in size.
+			return self.thumbnailPages(width, height, normalize)
+		}
 
-	ratio = math.Max(float64(self.Width())/float64(width), float64(self.Height())/float64(height))
+		// Animations may be optimized, every frame must be a complete image
+		// before resizing.
+		err := self.Coalesce()
+		if err != nil {
+			return err
+		}
+	}
 
-	return self.thumbnail(width, height, ratio)
-}
+	// Normalizing image.
 
-func (self *Canvas) thumbnail(width uint, height uint, ratio float64) error {
+	ratio = normalize(float64(self.Width())/float64(width), float64(self.Height())/float64(height))
 
 	if ratio < 1.0 {
 		// Origin image is smaller than the thumbnail image.
-		// Empty replacement buffer with transparent background.
-		replacement := New()
+		// Extending it with a transparent background, keeping the original
+		// image in the center.
+		transparent := C.NewPixelWand()
+		defer C.DestroyPixelWand(transparent)
+
+		ctransparent := C.CString("none")
+		C.PixelSetColor(transparent, ctransparent)
+		C.free(unsafe.Pointer(ctransparent))
+
+		x := (int(self.Width()) - int(width)) / 2
+		y := (int(self.Height()) - int(height)) / 2
+
+		err := self.eachImage(func() error {
+			C.MagickSetImageBackgroundColor(self.wand, transparent)
+			if C.MagickExtentImage(self.wand, C.size_t(width), C.size_t(height), C.ssize_t(x), C.ssize_t(y)) == C.MagickFalse {
+				return self.magickError("extend image")
+			}
+			return nil
+		})
 
-		replacement.SetBackgroundColor("none")
-
-		replacement.Blank(width, height)
-
-		// Putting original image in the center of the replacement canvas.
-		replacement.AppendCanvas(self, int(int(width-self.Width())/2), int(int(height-self.Height())/2))
-
-		// Replacing wand
-		C.DestroyMagickWand(self.wand)
-
-		self.wand = C.CloneMagickWand(replacement.wand)
+		if err != nil {
+			return err
+		}
 
 	} else {
 		// Is bigger, just resizing.
@@ -397,26 +392,57 @@ func (self *Canvas) thumbnail(width uint, height uint, ratio float64) error {
 		return err
 	}
 
-	return nil
+	// Cropped frames keep their offsets, the thumbnail starts at the origin.
+	return self.eachImage(func() error {
+		C.MagickResetImagePage(self.wand, nil)
+		return nil
+	})
 }
 
-// Puts a canvas on top of the current one.
-func (self *Canvas) AppendCanvas(source *Canvas, x int, y int) error {
-	success := C.MagickCompositeImage(self.wand, source.wand, C.OverCompositeOp, C.ssize_t(x), C.ssize_t(y))
+// Private: makes a thumbnail of every frame on its own.
+func (self *Canvas) thumbnailPages(width uint, height uint, normalize func(float64, float64) float64) error {
+	wand := C.NewMagickWand()
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not append image: %s", self.Error())
+	for i := uint(0); i < self.Frames(); i++ {
+		page, err := self.Frame(i)
+
+		if err != nil {
+			C.DestroyMagickWand(wand)
+			return err
+		}
+
+		if err = page.thumbnail(width, height, normalize); err == nil {
+			C.MagickSetLastIterator(wand)
+			if C.MagickAddImage(wand, page.wand) == C.MagickFalse {
+				err = self.magickError("add frame")
+			}
+		}
+
+		page.Destroy()
+
+		if err != nil {
+			C.DestroyMagickWand(wand)
+			return err
+		}
 	}
 
+	self.replaceWand(wand)
+
 	return nil
 }
 
+// Puts a canvas on top of the current one. Same as Composite() with
+// OVER_COMPOSITE.
+func (self *Canvas) AppendCanvas(source *Canvas, x int, y int) error {
+	return self.Composite(source, OVER_COMPOSITE, x, y)
+}
+
 // Rotates the whole canvas.
 func (self *Canvas) RotateCanvas(rad float64) error {
 	success := C.MagickRotateImage(self.wand, self.bg, C.double(RAD_TO_DEG*rad))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not rotate image: %s", self.Error())
+		return self.magickError("rotate image")
 	}
 
 	return nil
@@ -432,7 +458,8 @@ func (self *Canvas) Height() uint {
 	return uint(C.MagickGetImageHeight(self.wand))
 }
 
-// Writes canvas to a file, returns true on success.
+// Writes canvas to a file, returns true on success. Canvases with more than
+// one frame are written as a multi-image file (e.g. an animated GIF).
 func (self *Canvas) Write(filename string) error {
 	err := self.Update()
 
@@ -440,29 +467,37 @@ func (self *Canvas) Write(filename string) error {
 		return err
 	}
 
+	var success C.MagickBooleanType
+
 	cfilename := C.CString(filename)
-	success := C.MagickWriteImage(self.wand, cfilename)
+	if self.Frames() > 1 {
+		success = C.MagickWriteImages(self.wand, cfilename, C.MagickTrue)
+	} else {
+		success = C.MagickWriteImage(self.wand, cfilename)
+	}
 	C.free(unsafe.Pointer(cfilename))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not write: %s", self.Error())
+		return self.magickError("write")
 	}
 
 	return nil
 }
 
-// Changes the size of the canvas, returns true on success.
+// Changes the size of every frame of the canvas, returns true on success.
 func (self *Canvas) Resize(width uint, height uint) error {
-	success := C.MagickResizeImage(self.wand, C.size_t(width), C.size_t(height), C.GaussianFilter, C.double(1.0))
+	return self.eachImage(func() error {
+		success := C.MagickResizeImage(self.wand, C.size_t(width), C.size_t(height), C.GaussianFilter, C.double(1.0))
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not resize: %s", self.Error())
-	}
+		if success == C.MagickFalse {
+			return self.magickError("resize")
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// Changes the size of the canvas using specified filter and blur, returns true on success.
+// Changes the size of every frame of the canvas using specified filter and blur, returns true on success.
 func (self *Canvas) ResizeWithFilter(width uint, height uint, filter uint, blur float32) error {
 	if width == 0 && height == 0 {
 		return errors.New("Please specify at least one of dimensions")
@@ -482,13 +517,15 @@ func (self *Canvas) ResizeWithFilter(width uint, height uint, filter uint, blur
 		}
 	}
 
-	success := C.MagickResizeImage(self.wand, C.size_t(width), C.size_t(height), C.FilterTypes(filter), C.double(blur))
+	return self.eachImage(func() error {
+		success := C.MagickResizeImage(self.wand, C.size_t(width), C.size_t(height), C.FilterTypes(filter), C.double(blur))
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not resize: %s", self.Error())
-	}
+		if success == C.MagickFalse {
+			return self.magickError("resize")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // Sharpens an image. We convolve the image with a Gaussian operator of the
@@ -503,7 +540,7 @@ func (self *Canvas) SharpenImage(radius float32, sigma float32, channel int) err
 
 	success := C.MagickSharpenImageChannel(self.wand, C.ChannelType(channel), C.double(radius), C.double(sigma))
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not resize: %s", self.Error())
+		return self.magickError("sharpen image")
 	}
 
 	return nil
@@ -513,10 +550,12 @@ func (self *Canvas) GetImageBlob() ([]byte, error) {
 	return self.Blob()
 }
 
+// Returns the canvas encoded in its current format. Every frame is included
+// when the format supports multiple images (e.g. GIF or TIFF).
 func (self *Canvas) Blob() ([]byte, error) {
 	var size C.size_t = 0
 
-	p := unsafe.Pointer(C.MagickGetImageBlob(self.wand, &size))
+	p := unsafe.Pointer(C.MagickGetImagesBlob(self.wand, &size))
 
 	if size == 0 {
 		return nil, errors.New("Could not get image blob.")
@@ -529,23 +568,145 @@ func (self *Canvas) Blob() ([]byte, error) {
 	return blob, nil
 }
 
-// Adaptively changes the size of the canvas, returns true on success.
-func (self *Canvas) AdaptiveResize(width uint, height uint) error {
-	success := C.MagickAdaptiveResizeImage(self.wand, C.size_t(width), C.size_t(height))
+// Reads an image or image sequence from r until EOF, returns the number of
+// bytes read. Implements io.ReaderFrom.
+func (self *Canvas) ReadFrom(r io.Reader) (int64, error) {
+	return self.ReadFromFormat(r, "")
+}
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not resize: %s", self.Error())
+// Reads an image or image sequence from r until EOF using format (e.g. "PNG")
+// as a hint for inputs that can't be identified by their contents. An empty
+// format means the image format will be detected.
+func (self *Canvas) ReadFromFormat(r io.Reader, format string) (int64, error) {
+	buf := &bytes.Buffer{}
+
+	n, err := buf.ReadFrom(r)
+
+	if err != nil {
+		return n, err
+	}
+
+	if n == 0 {
+		return n, errors.New("Could not open image from reader: no data.")
+	}
+
+	if format != "" {
+		cformat := C.CString(format)
+		defer C.free(unsafe.Pointer(cformat))
+
+		C.MagickSetFormat(self.wand, cformat)
+
+		// Format hint must not leak into the next read.
+		cempty := C.CString("")
+		defer C.free(unsafe.Pointer(cempty))
+		defer C.MagickSetFormat(self.wand, cempty)
+	}
+
+	blob := buf.Bytes()
+
+	if err = self.checkBlobLimits(blob, format); err != nil {
+		return n, err
+	}
+
+	status := C.MagickReadImageBlob(self.wand, unsafe.Pointer(&blob[0]), C.size_t(len(blob)))
+
+	if status == C.MagickFalse {
+		return n, self.magickError("open image from reader")
+	}
+
+	return n, nil
+}
+
+// Writes the image or image sequence to w, returns the number of bytes
+// written. Implements io.WriterTo.
+func (self *Canvas) WriteTo(w io.Writer) (int64, error) {
+	return self.WriteToFormat(w, "")
+}
+
+// Writes the image or image sequence to w encoded as format (e.g. "JPEG"). An
+// empty format keeps the format of each image.
+func (self *Canvas) WriteToFormat(w io.Writer, format string) (int64, error) {
+	err := self.Update()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if format != "" {
+		cformat := C.CString(format)
+		defer C.free(unsafe.Pointer(cformat))
+
+		err = self.eachImage(func() error {
+			if C.MagickSetImageFormat(self.wand, cformat) == C.MagickFalse {
+				return self.magickError("set format")
+			}
+			return nil
+		})
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var size C.size_t = 0
+
+	p := unsafe.Pointer(C.MagickGetImagesBlob(self.wand, &size))
+
+	if size == 0 {
+		return 0, self.magickError("write to writer")
+	}
+
+	blob := C.GoBytes(p, C.int(size))
+
+	C.MagickRelinquishMemory(p)
+
+	n, err := w.Write(blob)
+
+	return int64(n), err
+}
+
+// Private: calls fn once for every image in the wand, with the wand's
+// iterator pointing to that image. The original iterator position is
+// restored afterwards.
+func (self *Canvas) eachImage(fn func() error) error {
+	n := int(C.MagickGetNumberImages(self.wand))
+
+	if n == 0 {
+		return nil
+	}
+
+	index := C.MagickGetIteratorIndex(self.wand)
+	defer C.MagickSetIteratorIndex(self.wand, index)
+
+	for i := 0; i < n; i++ {
+		C.MagickSetIteratorIndex(self.wand, C.ssize_t(i))
+		if err := fn(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// Adaptively changes the size of every frame of the canvas, returns true on success.
+func (self *Canvas) AdaptiveResize(width uint, height uint) error {
+	return self.eachImage(func() error {
+		success := C.MagickAdaptiveResizeImage(self.wand, C.size_t(width), C.size_t(height))
+
+		if success == C.MagickFalse {
+			return self.magickError("resize")
+		}
+
+		return nil
+	})
+}
+
 // Changes the compression quality of the canvas. Ranges from 1 (lowest) to 100 (highest).
 func (self *Canvas) SetQuality(quality uint) error {
 	success := C.MagickSetImageCompressionQuality(self.wand, C.size_t(quality))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set compression quality: %s", self.Error())
+		return self.magickError("set compression quality")
 	}
 
 	return nil
@@ -574,13 +735,13 @@ func (self *Canvas) SetBackgroundColor(color string) error {
 	C.free(unsafe.Pointer(ccolor))
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not set pixel color: %s", self.Error())
+		return self.magickError("set pixel color")
 	}
 
 	status = C.MagickSetImageBackgroundColor(self.wand, self.bg)
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not set background color: %s", self.Error())
+		return self.magickError("set background color")
 	}
 
 	return nil
@@ -645,11 +806,16 @@ func (self *Canvas) StrokeLineJoin() uint {
 	return uint(C.DrawGetStrokeLineJoin(self.drawing))
 }
 
-/*
-func (self *Canvas) SetFillRule(value int) {
-  C.DrawSetFillRule(self.drawing, C.FillRule(value))
+// Sets the rule used to decide which parts of a path or polygon are filled,
+// see the FILL_*_RULE constants.
+func (self *Canvas) SetFillRule(value uint) {
+	C.DrawSetFillRule(self.drawing, C.FillRule(value))
+}
+
+// Returns the rule used to decide which parts of a path or polygon are filled.
+func (self *Canvas) FillRule() uint {
+	return uint(C.DrawGetFillRule(self.drawing))
 }
-*/
 
 // Sets the fill color for enclosed areas on the current drawing surface.
 func (self *Canvas) SetFillColor(color string) {
@@ -725,7 +891,7 @@ func (self *Canvas) PushDrawing() error {
 	success := C.PushDrawingWand(self.drawing)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not push surface: %s", self.Error())
+		return self.magickError("push surface")
 	}
 
 	return nil
@@ -736,7 +902,7 @@ func (self *Canvas) PopDrawing() error {
 	success := C.PopDrawingWand(self.drawing)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not pop surface: %s", self.Error())
+		return self.magickError("pop surface")
 	}
 
 	return nil
@@ -747,7 +913,7 @@ func (self *Canvas) Update() error {
 	success := C.MagickDrawImage(self.wand, self.drawing)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not update image: %s", self.Error())
+		return self.magickError("update image")
 	}
 
 	return nil
@@ -788,11 +954,6 @@ func (self *Canvas) Destroy() error {
 		self.wand = nil
 	}
 
-	if self.text != nil && self.text.UnderColor != nil {
-		C.DestroyPixelWand(self.text.UnderColor)
-		self.text.UnderColor = nil
-	}
-
 	return nil
 }
 
@@ -805,7 +966,7 @@ func (self *Canvas) Blank(width uint, height uint) error {
 	success := C.MagickNewImage(self.wand, C.size_t(width), C.size_t(height), self.bg)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not create image: %s", self.Error())
+		return self.magickError("create image")
 	}
 
 	return nil
@@ -816,7 +977,7 @@ func (self *Canvas) Blur(sigma float64) error {
 	success := C.MagickBlurImage(self.wand, C.double(0), C.double(sigma))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not blur image: %s", self.Error())
+		return self.magickError("blur image")
 	}
 
 	return nil
@@ -827,7 +988,7 @@ func (self *Canvas) AdaptiveBlur(sigma float64) error {
 	success := C.MagickAdaptiveBlurImage(self.wand, C.double(0), C.double(sigma))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not blur image: %s", self.Error())
+		return self.magickError("blur image")
 	}
 
 	return nil
@@ -838,7 +999,7 @@ func (self *Canvas) AddNoise() error {
 	success := C.MagickAddNoiseImage(self.wand, C.GaussianNoise)
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not add noise: %s", self.Error())
+		return self.magickError("add noise")
 	}
 
 	return nil
@@ -849,26 +1010,28 @@ func (self *Canvas) Chop(x int, y int, width uint, height uint) error {
 	success := C.MagickChopImage(self.wand, C.size_t(width), C.size_t(height), C.ssize_t(x), C.ssize_t(y))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not chop: %s", self.Error())
+		return self.magickError("chop")
 	}
 
 	return nil
 }
 
-// Extracts a region from the canvas.
+// Extracts a region from every frame of the canvas.
 func (self *Canvas) Crop(x int, y int, width uint, height uint) error {
-	success := C.MagickCropImage(self.wand, C.size_t(width), C.size_t(height), C.ssize_t(x), C.ssize_t(y))
+	return self.eachImage(func() error {
+		success := C.MagickCropImage(self.wand, C.size_t(width), C.size_t(height), C.ssize_t(x), C.ssize_t(y))
 
-	if success == C.MagickFalse {
-		return fmt.Errorf("Could not crop: %s", self.Error())
-	}
+		if success == C.MagickFalse {
+			return self.magickError("crop")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (self *Canvas) SetSize(width, height uint) error {
 	if C.MagickSetSize(self.wand, C.size_t(width), C.size_t(height)) == C.MagickFalse {
-		return fmt.Errorf("Could not set size: %s", self.Error())
+		return self.magickError("set size")
 	}
 
 	return nil
@@ -881,7 +1044,7 @@ func (self *Canvas) SetContrast(factor float64) error {
 	success := C.MagickBrightnessContrastImage(self.wand, 0, C.double(factor))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set contrast: %s", self.Error())
+		return self.magickError("set contrast")
 	}
 
 	return nil
@@ -895,7 +1058,7 @@ func (self *Canvas) SetBrightness(factor float64) error {
 	success := C.MagickModulateImage(self.wand, C.double(100+factor*100.0), C.double(100), C.double(100))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set brightness: %s", self.Error())
+		return self.magickError("set brightness")
 	}
 
 	return nil
@@ -910,7 +1073,7 @@ func (self *Canvas) SetSaturation(factor float64) error {
 	success := C.MagickModulateImage(self.wand, C.double(100), C.double(100+factor*100.0), C.double(100))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set saturation: %s", self.Error())
+		return self.magickError("set saturation")
 	}
 
 	return nil
@@ -925,7 +1088,7 @@ func (self *Canvas) SetHue(factor float64) error {
 	success := C.MagickModulateImage(self.wand, C.double(100), C.double(100), C.double(100+factor*100.0))
 
 	if success == C.MagickFalse {
-		return fmt.Errorf("Could not set hue: %s", self.Error())
+		return self.magickError("set hue")
 	}
 
 	return nil
@@ -937,7 +1100,7 @@ func (self *Canvas) InterlaceScheme() uint {
 
 func (self *Canvas) SetInterlaceScheme(scheme uint) error {
 	if C.MagickSetImageInterlaceScheme(self.wand, C.InterlaceType(scheme)) == C.MagickFalse {
-		return fmt.Errorf("Could not set interlace scheme: %s", self.Error())
+		return self.magickError("set interlace scheme")
 	}
 
 	return nil
@@ -949,7 +1112,7 @@ func (self *Canvas) SetFormat(format string) error {
 	defer C.free(unsafe.Pointer(cformat))
 
 	if C.MagickSetImageFormat(self.wand, cformat) == C.MagickFalse {
-		return fmt.Errorf("Could not set format: %s", self.Error())
+		return self.magickError("set format")
 	}
 
 	return nil
@@ -972,7 +1135,7 @@ func (self *Canvas) Strip() error {
 	status = C.MagickStripImage(self.wand)
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not strip: %s", self.Error())
+		return self.magickError("strip")
 	}
 
 	return nil
@@ -984,7 +1147,7 @@ func (self *Canvas) SetType(imageType uint) error {
 	status = C.MagickSetImageType(self.wand, C.ImageType(imageType))
 
 	if status == C.MagickFalse {
-		return fmt.Errorf("Could not set type: %s", self.Error())
+		return self.magickError("set type")
 	}
 
 	return nil
@@ -1000,7 +1163,7 @@ func (self *Canvas) SetSepiaTone(threshold float64) error {
 	threshold = (float64(self.QuantumRange()) * threshold) / 100.0
 
 	if C.MagickSepiaToneImage(self.wand, C.double(threshold)) == C.MagickFalse {
-		return fmt.Errorf("Could not apply sepia effect: %s", self.Error())
+		return self.magickError("apply sepia effect")
 	}
 
 	return nil
@@ -1022,8 +1185,24 @@ func (self *Canvas) SetGravity(gravity uint) {
 	C.MagickSetGravity(self.wand, C.GravityType(gravity))
 }
 
-func (self *Canvas) PixelIterator(x, y int, width, height uint) *PixelIterator {
-	return &PixelIterator{iterator: C.NewPixelRegionIterator(self.wand, C.ssize_t(x), C.ssize_t(y), C.size_t(width), C.size_t(height))}
+// Returns an iterator over the rows of the rectangle (x, y, width, height)
+// of the current image. The caller is responsible for destroying it.
+func (self *Canvas) PixelIterator(x, y int, width, height uint) (*PixelIterator, error) {
+	if width == 0 || height == 0 {
+		return nil, errors.New("Could not create pixel iterator: region is empty.")
+	}
+
+	if x < 0 || y < 0 || uint(x)+width > self.Width() || uint(y)+height > self.Height() {
+		return nil, fmt.Errorf("Could not create pixel iterator: region %dx%d+%d+%d is out of the %dx%d image.", width, height, x, y, self.Width(), self.Height())
+	}
+
+	iterator := C.NewPixelRegionIterator(self.wand, C.ssize_t(x), C.ssize_t(y), C.size_t(width), C.size_t(height))
+
+	if iterator == nil {
+		return nil, self.magickError("create pixel iterator")
+	}
+
+	return &PixelIterator{iterator: iterator, height: height}, nil
 }
 
 // Returns a new canvas object.
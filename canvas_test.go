@@ -2,10 +2,17 @@ package canvas
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
 	"io"
 	"math"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 /*
@@ -36,6 +43,73 @@ func TestMalformed(t *testing.T) {
 
 }
 
+func TestMagickError(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	err := canvas.Open("_examples/input/malformed.png")
+
+	var magickErr *MagickError
+
+	if !errors.As(err, &magickErr) {
+		t.Fatalf("Expecting a *MagickError, got %v.", err)
+	}
+
+	if magickErr.Severity == UndefinedSeverity || magickErr.Message == "" {
+		t.Errorf("Unexpected error: %#v", magickErr)
+	}
+
+	if canvas.Error() != nil {
+		t.Errorf("Exception should have been cleared.")
+	}
+
+	warning := &MagickError{Op: "open image", Code: ErrCorruptImage.Code - 100, Severity: WarningSeverity}
+
+	if !errors.Is(warning, ErrCorruptImage) || errors.Is(warning, ErrMissingDelegate) {
+		t.Errorf("Could not match %v against sentinel errors.", warning)
+	}
+
+	if !IsWarning(fmt.Errorf("wrapped: %w", warning)) || IsWarning(ErrCorruptImage) {
+		t.Errorf("Could not tell warnings apart.")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetLimits(Limits{Pixels: 100 * 100})
+
+	err := canvas.Open("_examples/input/example.png")
+
+	var limitErr *LimitError
+
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrResourceLimit) {
+		t.Fatalf("Expecting a *LimitError, got %v.", err)
+	}
+
+	if canvas.Frames() != 0 {
+		t.Errorf("Image should not have been decoded.")
+	}
+
+	canvas.SetLimits(Limits{Width: 10000, Height: 10000})
+
+	if err = canvas.Open("_examples/input/example.png"); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	limit := ResourceLimit(MEMORY_RESOURCE)
+	defer SetResourceLimit(MEMORY_RESOURCE, limit)
+
+	if err = SetResourceLimit(MEMORY_RESOURCE, 256<<20); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if got := ResourceLimit(MEMORY_RESOURCE); got != 256<<20 {
+		t.Errorf("Got %d, expecting %d.", got, 256<<20)
+	}
+}
+
 func TestOpenWrite(t *testing.T) {
 	canvas := New()
 
@@ -85,360 +159,2332 @@ func TestOpenBlobWrite(t *testing.T) {
 	canvas.Destroy()
 }
 
-func TestThumbnail(t *testing.T) {
+func TestReadFromWriteTo(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	err := canvas.Open("_examples/input/example.png")
-
-	if err == nil {
-		canvas.AutoOrientate()
+	file, err := os.Open("_examples/input/example.png")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.Thumbnail(100, 100)
+	defer file.Close()
 
-		canvas.Write("_examples/output/example-thumbnail.png")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	if _, err = canvas.ReadFrom(file); err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
-}
+	buf := &bytes.Buffer{}
 
-func TestFit(t *testing.T) {
-	canvas := New()
+	n, err := canvas.WriteToFormat(buf, "JPEG")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	err := canvas.Open("_examples/input/example.png")
+	if n == 0 || int64(buf.Len()) != n {
+		t.Errorf("Got %d bytes, expecting %d.", buf.Len(), n)
+	}
 
-	if err == nil {
-		canvas.AutoOrientate()
+	clone := New()
+	defer clone.Destroy()
 
-		canvas.Fit(100, 100)
-		
-		canvas.Write("_examples/output/example-fit.png")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	if _, err = clone.ReadFromFormat(buf, "JPEG"); err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
+	if clone.Width() != canvas.Width() || clone.Height() != canvas.Height() {
+		t.Errorf("Got %dx%d, expecting %dx%d.", clone.Width(), clone.Height(), canvas.Width(), canvas.Height())
+	}
 }
-// https://github.com/gosexy/canvas/issues/3
-func TestThumbnailIssue3(t *testing.T) {
+
+func TestImageConversion(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
 	err := canvas.Open("_examples/input/example.png")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	if err == nil {
-		canvas.AutoOrientate()
-
-		canvas.Thumbnail(2000, 2000)
-
-		canvas.Write("_examples/output/example-bigger-than-original-thumbnail.png")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	img, err := canvas.ToImage()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
-}
+	if bounds := img.Bounds(); uint(bounds.Dx()) != canvas.Width() || uint(bounds.Dy()) != canvas.Height() {
+		t.Errorf("Got %dx%d, expecting %dx%d.", bounds.Dx(), bounds.Dy(), canvas.Width(), canvas.Height())
+	}
 
-func TestClone(t *testing.T) {
-	canvas := New()
+	gray := image.NewGray(image.Rect(0, 0, 16, 8))
+	gray.Pix[0] = 0xff
 
-	err := canvas.Open("_examples/input/example.png")
+	converted, err := FromImage(gray)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	if err == nil {
+	defer converted.Destroy()
 
-		clone := canvas.Clone()
-		clone.Resize(100, 100)
-		clone.Write("_examples/output/cloned-100x100.png")
+	img, err = converted.ToImage()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		clone.Destroy()
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("Got %d, expecting %d.", r, 0xffff)
+	}
 
-		canvas.Write("_examples/output/not-cloned.png")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	if r, _, _, _ := img.At(1, 0).RGBA(); r != 0 {
+		t.Errorf("Got %d, expecting %d.", r, 0)
 	}
 
-	canvas.Destroy()
+	converted.Write("_examples/output/example-from-image.png")
 }
 
-func TestResize(t *testing.T) {
+func TestExportImportPixels(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	err := canvas.Open("_examples/input/example.png")
+	canvas.SetBackgroundColor("#ff8000")
+	canvas.Blank(4, 3)
 
-	if err == nil {
-		canvas.Resize(100, 100)
-		canvas.Write("_examples/output/example-100x100.png")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	pixels, err := canvas.ExportPixels(0, 0, 4, 3, "BGR", CharStorage)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
-}
+	bgr := pixels.([]uint8)
 
-func TestResizeWithFilter(t *testing.T) {
-	canvas := New()
+	if len(bgr) != 4*3*3 {
+		t.Fatalf("Got %d values, expecting %d.", len(bgr), 4*3*3)
+	}
 
-	err := canvas.Open("_examples/input/example.jpg")
+	if bgr[0] != 0x00 || bgr[1] != 0x80 || bgr[2] != 0xff {
+		t.Errorf("Got %v, expecting [0 128 255].", bgr[:3])
+	}
 
-	if err == nil {
-		canvas.ResizeWithFilter(170, 0, HANNING_FILTER, 5.0)
-		canvas.Write("_examples/output/example-hanning-filter.jpg")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	gray := make([]float64, 4*3)
+	for i := range gray {
+		gray[i] = float64(i) / float64(len(gray)-1)
 	}
 
-	canvas.Destroy()
-}
+	if err = canvas.ImportPixels(0, 0, 4, 3, "I", gray); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-func TestSharpenImage(t *testing.T) {
-	canvas := New()
+	pixels, err = canvas.ExportPixels(3, 2, 1, 1, "R", ShortStorage)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	err := canvas.Open("_examples/input/example.jpg")
+	if r := pixels.([]uint16)[0]; r != 0xffff {
+		t.Errorf("Got %d, expecting %d.", r, 0xffff)
+	}
 
-	if err == nil {
-		canvas.SharpenImage(1.0, 1.0, 0)
-		canvas.Write("_examples/output/example-sharpen-image.jpg")
-	} else {
-		t.Errorf("Error: %s\n", err)
+	if err = canvas.ImportPixels(0, 0, 4, 3, "RGB", gray); err == nil {
+		t.Errorf("Test should have failed.")
 	}
 
-	canvas.Destroy()
+	if _, err = canvas.ExportPixels(0, 0, 4, 3, "", FloatStorage); err == nil {
+		t.Errorf("Test should have failed.")
+	}
 }
 
-func TestGetImageBlob(t *testing.T) {
+func TestTypedPixels(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	err := canvas.Open("_examples/input/example.jpg")
+	canvas.SetBackgroundColor("#ff8000")
+	canvas.Blank(4, 3)
 
-	if err == nil {
-		if blob, err := canvas.GetImageBlob(); err != nil || len(blob) == 0 {
-			t.Errorf("Error: can not get image blob")
-		}
-	} else {
-		t.Errorf("Error: %s\n", err)
+	rgb, err := canvas.ExportPixels8(0, 0, 4, 3, "RGB")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
-}
+	if len(rgb) != 4*3*3 || rgb[0] != 0xff || rgb[1] != 0x80 || rgb[2] != 0x00 {
+		t.Errorf("Got %v, expecting [255 128 0].", rgb[:3])
+	}
 
-func TestBlank(t *testing.T) {
-	canvas := New()
+	if err = canvas.ImportPixels16(1, 1, 1, 1, "RGB", []uint16{0, 0xffff, 0}); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	canvas.SetBackgroundColor("#00ff00")
+	green, err := canvas.ExportPixelsFloat64(1, 1, 1, 1, "G")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	err := canvas.Blank(400, 400)
+	if green[0] != 1 {
+		t.Errorf("Got %v, expecting %v.", green[0], 1)
+	}
 
-	if err == nil {
-		canvas.Write("_examples/output/example-blank.png")
-	} else {
-		t.Errorf("Failed to create blank image.")
+	if err = canvas.ImportPixelsFloat32(0, 0, 4, 3, "RGB", make([]float32, 4*3)); err == nil {
+		t.Errorf("Test should have failed.")
 	}
 
-	canvas.Destroy()
+	if _, err = canvas.ExportPixels16(0, 0, 0, 3, "RGB"); err == nil {
+		t.Errorf("Test should have failed.")
+	}
 }
 
-func TestSettersAndGetters(t *testing.T) {
-
+func TestPixel(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	err := canvas.Blank(400, 400)
+	canvas.SetBackgroundColor("#ff0000")
+	canvas.Blank(2, 2)
 
+	iterator, err := canvas.PixelIterator(0, 0, 2, 2)
 	if err != nil {
-		t.Errorf("Could not create blank image.")
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	const backgroundColor = "#112233"
-
-	canvas.SetBackgroundColor(backgroundColor)
+	defer iterator.Destroy()
 
-	if gotBackgroundColor := canvas.BackgroundColor(); gotBackgroundColor != backgroundColor {
-		t.Errorf("Got %s, expecting %s", gotBackgroundColor, backgroundColor)
+	row := iterator.NextRow()
+	if len(row) != 2 {
+		t.Fatalf("Got %d pixels, expecting %d.", len(row), 2)
 	}
 
-	const strokeAntialias = true
-
-	canvas.SetStrokeAntialias(strokeAntialias)
+	if hex := row[0].HexColor(); hex != "#ff0000ff" {
+		t.Errorf("Got %s, expecting %s.", hex, "#ff0000ff")
+	}
 
-	if gotStrokeAntialias := canvas.StrokeAntialias(); gotStrokeAntialias != strokeAntialias {
-		t.Errorf("Got %t, expecting %t.", gotStrokeAntialias, strokeAntialias)
+	if h, s, _ := row[0].HSL(); h != 0 || s != 1 {
+		t.Errorf("Got %f, %f, expecting %f, %f.", h, s, 0.0, 1.0)
 	}
 
-	const strokeWidth = 2.0
+	row[0].SetRGBA(0, 0, 1, 1)
+	row[1].SetFromColor(color.NRGBA{0x00, 0xff, 0x00, 0xff})
 
-	canvas.SetStrokeWidth(strokeWidth)
+	if err := iterator.Sync(); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	if gotStrokeWidth := canvas.StrokeWidth(); gotStrokeWidth != strokeWidth {
-		t.Errorf("Got %f, expecting %f.", gotStrokeWidth, strokeWidth)
+	pixels, err := canvas.ExportPixels(0, 0, 2, 1, "RGBA", CharStorage)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	const strokeOpacity = 1.0
+	expected := []uint8{0x00, 0x00, 0xff, 0xff, 0x00, 0xff, 0x00, 0xff}
 
-	canvas.SetStrokeOpacity(strokeOpacity)
+	if !bytes.Equal(pixels.([]uint8), expected) {
+		t.Errorf("Got %v, expecting %v.", pixels, expected)
+	}
 
-	if gotStrokeOpacity := canvas.StrokeOpacity(); gotStrokeOpacity != strokeOpacity {
-		t.Errorf("Got %f, expecting %f.", gotStrokeOpacity, strokeOpacity)
+	if r, g, b, a := row[1].RGBA(); r != 0 || g != 0xffff || b != 0 || a != 0xffff {
+		t.Errorf("Got %d %d %d %d, expecting 0 65535 0 65535.", r, g, b, a)
 	}
+}
 
-	strokeLineCap := STROKE_SQUARE_CAP
+func TestPixelIterator(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
 
-	canvas.SetStrokeLineCap(strokeLineCap)
+	canvas.SetBackgroundColor("#000000")
+	canvas.Blank(3, 4)
 
-	if gotStrokeLineCap := canvas.StrokeLineCap(); gotStrokeLineCap != strokeLineCap {
-		t.Errorf("Got %d, expecting %d.", gotStrokeLineCap, strokeLineCap)
+	if _, err := canvas.PixelIterator(0, 0, 3, 5); err == nil {
+		t.Errorf("Test should have failed.")
 	}
 
-	strokeLineJoin := STROKE_ROUND_JOIN
-
-	canvas.SetStrokeLineJoin(strokeLineJoin)
-
-	if gotStrokeLineJoin := canvas.StrokeLineJoin(); gotStrokeLineJoin != strokeLineJoin {
-		t.Errorf("Got %d, expecting %d.", gotStrokeLineJoin, strokeLineJoin)
+	iterator, err := canvas.PixelIterator(0, 0, 3, 4)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	const fillColor = "#112233"
+	defer iterator.Destroy()
 
-	canvas.SetFillColor(fillColor)
+	rows := 0
 
-	if gotFillColor := canvas.FillColor(); gotFillColor != fillColor {
-		t.Errorf("Got %s, expecting %s", gotFillColor, fillColor)
-	}
+	for y, row := range iterator.All() {
+		if y != rows {
+			t.Errorf("Got %d, expecting %d.", y, rows)
+		}
 
-	const strokeColor = "#112233"
+		for _, pixel := range row {
+			pixel.SetRGBA(float64(y)/3, 0, 0, 1)
+		}
 
-	canvas.SetStrokeColor(strokeColor)
+		if err := iterator.Sync(); err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
 
-	if gotStrokeColor := canvas.StrokeColor(); gotStrokeColor != strokeColor {
-		t.Errorf("Got %s, expecting %s", gotStrokeColor, strokeColor)
+		rows++
 	}
 
-	const quality = 76
-
-	canvas.SetQuality(quality)
+	if rows != 4 {
+		t.Errorf("Got %d rows, expecting %d.", rows, 4)
+	}
 
-	if gotQuality := canvas.Quality(); gotQuality != quality {
-		t.Errorf("Got %d, expecting %d", gotQuality, quality)
+	if err = iterator.SetRow(2); err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	const interlaceScheme = NO_INTERLACE
+	if row := iterator.CurrentRow(); iterator.Row() != 2 || len(row) != 3 || row[0].Red() < 0.6 || row[0].Red() > 0.7 {
+		t.Errorf("Unexpected row %d.", iterator.Row())
+	}
 
-	canvas.SetInterlaceScheme(interlaceScheme)
+	if row := iterator.PreviousRow(); iterator.Row() != 1 || row == nil {
+		t.Errorf("Got row %d, expecting %d.", iterator.Row(), 1)
+	}
 
-	if gotInterlaceScheme := canvas.InterlaceScheme(); interlaceScheme != interlaceScheme {
-		t.Errorf("Got %d, expecting %d", gotInterlaceScheme, interlaceScheme)
+	if err = iterator.SetRow(4); err == nil {
+		t.Errorf("Test should have failed.")
 	}
 
-	canvas.Destroy()
-}
+	iterator.Reset()
 
-func TestDrawLine(t *testing.T) {
+	if row := iterator.NextRow(); iterator.Row() != 0 || row[0].Red() != 0 {
+		t.Errorf("Got row %d, expecting %d.", iterator.Row(), 0)
+	}
+}
 
+func TestHistogram(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	canvas.SetBackgroundColor("#000000")
+	canvas.SetBackgroundColor("#ff0000")
+	canvas.Blank(10, 10)
 
-	err := canvas.Blank(400, 400)
+	canvas.SetFillColor("#0000ff")
+	canvas.SetStrokeColor("none")
+	canvas.Rectangle(2, 10)
+	canvas.Update()
 
-	if err == nil {
+	histogram, err := canvas.Histogram()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.Translate(200, 200)
-		canvas.SetStrokeWidth(10)
-		canvas.SetStrokeColor("#ffffff")
-		canvas.Line(100, 100)
+	if len(histogram) < 2 || histogram[0].Color != (color.NRGBA{0xff, 0x00, 0x00, 0xff}) {
+		t.Fatalf("Unexpected histogram: %v", histogram)
+	}
 
-		canvas.Write("_examples/output/example-line.png")
-	} else {
-		t.Errorf("Failed to create blank image.")
+	var total uint
+	for _, c := range histogram {
+		total += c.Count
 	}
 
-	canvas.Destroy()
+	if total != 100 {
+		t.Errorf("Got %d, expecting %d.", total, 100)
+	}
+
+	dominant, err := canvas.DominantColors(1)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if len(dominant) != 1 || dominant[0].Count != 100 {
+		t.Errorf("Unexpected dominant colors: %v", dominant)
+	}
+
+	average, err := canvas.AverageColor()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if average.R <= average.B || average.B == 0 || average.A != 0xff {
+		t.Errorf("Unexpected average color: %v", average)
+	}
 }
 
-func TestDrawCircle(t *testing.T) {
+func TestStatistics(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
 	canvas.SetBackgroundColor("#000000")
+	canvas.Blank(10, 10)
 
-	err := canvas.Blank(400, 400)
+	stats, err := canvas.Statistics()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	if err == nil {
+	if stats.Composite.Max != 0 || stats.Red.Entropy != 0 || stats.Alpha.Mean != 1 {
+		t.Errorf("Unexpected statistics: %v", stats)
+	}
 
-		canvas.SetFillColor("#ff0000")
+	if opaque, err := canvas.IsOpaque(); err != nil || !opaque {
+		t.Errorf("Image should be opaque: %v", err)
+	}
 
-		canvas.PushDrawing()
-		canvas.Translate(200, 200)
-		canvas.SetStrokeWidth(5)
-		canvas.SetStrokeColor("#ffffff")
-		canvas.Circle(100)
-		canvas.PopDrawing()
+	if gray, err := canvas.IsGrayscale(); err != nil || !gray {
+		t.Errorf("Image should be grayscale: %v", err)
+	}
 
-		canvas.PushDrawing()
-		canvas.Translate(100, 100)
-		canvas.SetStrokeWidth(3)
-		canvas.SetStrokeColor("#ffffff")
-		canvas.Circle(20)
-		canvas.PopDrawing()
+	if err = canvas.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.Write("_examples/output/example-circle.png")
-	} else {
-		t.Errorf("Failed to create blank image.")
+	if stats, err = canvas.Statistics(); err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
-	canvas.Destroy()
+	if stats.Red.Mean <= 0 || stats.Red.StandardDeviation <= 0 || stats.Composite.Entropy <= 0 || stats.Composite.Entropy > 1 {
+		t.Errorf("Unexpected statistics: %v", stats)
+	}
+
+	min, max, err := canvas.Range()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if min >= max || max > 1 {
+		t.Errorf("Got %f, %f, expecting min < max <= 1.", min, max)
+	}
+
+	if gray, _ := canvas.IsGrayscale(); gray {
+		t.Errorf("Image should not be grayscale.")
+	}
 }
 
-func TestDrawRectangle(t *testing.T) {
+func TestCompare(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	canvas.SetBackgroundColor("#000000")
+	if err := canvas.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	err := canvas.Blank(400, 400)
+	canvas.Resize(200, 150)
 
-	if err == nil {
+	clone, err := canvas.Frame(0)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.SetFillColor("#ff0000")
+	defer clone.Destroy()
 
-		canvas.Translate(200-50, 200+75)
-		canvas.SetStrokeWidth(5)
-		canvas.SetStrokeColor("#ffffff")
-		canvas.Rectangle(100, -150)
+	distortion, diff, err := canvas.Compare(clone, MEAN_ABSOLUTE_ERROR_METRIC)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.Write("_examples/output/example-rectangle.png")
-	} else {
-		t.Errorf("Failed to create blank image.")
+	diff.Destroy()
+
+	if distortion != 0 {
+		t.Errorf("Got %f, expecting %f.", distortion, 0.0)
 	}
 
-	canvas.Destroy()
+	similarity, diff, err := canvas.Compare(clone, SSIM_METRIC)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	diff.Destroy()
+
+	if math.Abs(similarity-1) > 1e-9 {
+		t.Errorf("Got %f, expecting %f.", similarity, 1.0)
+	}
+
+	clone.Blur(3)
+
+	similarity, diff, err = canvas.Compare(clone, SSIM_METRIC)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	defer diff.Destroy()
+
+	if similarity >= 1 || similarity <= 0 {
+		t.Errorf("Got %f, expecting a value between 0 and 1.", similarity)
+	}
+
+	diff.Write("_examples/output/example-compare-ssim.png")
+
+	other := New()
+	defer other.Destroy()
+
+	other.Blank(10, 10)
+
+	if _, _, err = canvas.Compare(other, ROOT_MEAN_SQUARED_ERROR_METRIC); err == nil {
+		t.Errorf("Test should have failed.")
+	}
 }
 
-func TestDrawEllipse(t *testing.T) {
+func TestHash(t *testing.T) {
 	canvas := New()
+	defer canvas.Destroy()
 
-	err := canvas.Blank(400, 400)
+	if err := canvas.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-	if err == nil {
+	resized, err := canvas.Frame(0)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.SetFillColor("#ff0000")
+	defer resized.Destroy()
 
-		canvas.PushDrawing()
-		canvas.Translate(200, 200)
-		canvas.Rotate(math.Pi / 3)
-		canvas.Ellipse(50, 180)
-		canvas.PopDrawing()
+	resized.Resize(canvas.Width()/2, canvas.Height()/2)
 
-		canvas.SetFillColor("#ff00ff")
+	flipped, err := canvas.Frame(0)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
 
-		canvas.PushDrawing()
-		canvas.Translate(200, 200)
-		canvas.Rotate(-math.Pi / 3)
-		canvas.Ellipse(25, 90)
-		canvas.PopDrawing()
+	defer flipped.Destroy()
 
-		canvas.Write("_examples/output/example-ellipse.png")
-	} else {
-		t.Errorf("Failed to create blank image.")
+	flipped.Flip()
+
+	hashes := []func(c *Canvas) (Hash, error){
+		(*Canvas).AverageHash,
+		(*Canvas).DifferenceHash,
+		(*Canvas).PerceptualHash,
+	}
+
+	for _, hash := range hashes {
+		original, err := hash(canvas)
+		if err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+
+		near, _ := hash(resized)
+		far, _ := hash(flipped)
+
+		if original.Distance(near) > 10 {
+			t.Errorf("Got %d, expecting at most %d.", original.Distance(near), 10)
+		}
+
+		if original.Distance(far) <= original.Distance(near) {
+			t.Errorf("Got %d, expecting more than %d.", original.Distance(far), original.Distance(near))
+		}
+	}
+
+	original, err := canvas.MomentsHash()
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	near, _ := resized.MomentsHash()
+
+	if d := original.Distance(near); d > 1 {
+		t.Errorf("Got %f, expecting at most %f.", d, 1.0)
+	}
+
+	empty := New()
+	defer empty.Destroy()
+
+	if _, err = empty.PerceptualHash(); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	if err := canvas.Open("_examples/input/example.jpg"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.TransformColorspace(CMYK_COLORSPACE); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if cs := canvas.Colorspace(); cs != CMYK_COLORSPACE {
+		t.Errorf("Got %d, expecting %d.", cs, CMYK_COLORSPACE)
+	}
+
+	if err := canvas.TransformColorspace(RGB_COLORSPACE); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if cs := canvas.Colorspace(); cs == CMYK_COLORSPACE {
+		t.Errorf("Got %d, expecting a RGB colorspace.", cs)
+	}
+
+	data := []byte("canvas test profile")
+
+	if err := canvas.SetProfile("test", data); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	found := false
+	for _, name := range canvas.Profiles() {
+		found = found || name == "test"
+	}
+
+	if !found {
+		t.Errorf("Profile not found in %v.", canvas.Profiles())
+	}
+
+	if got := canvas.Profile("test"); !bytes.Equal(got, data) {
+		t.Errorf("Got %q, expecting %q.", got, data)
+	}
+
+	if err := canvas.RemoveProfile("test"); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if got := canvas.Profile("test"); got != nil {
+		t.Errorf("Profile should have been removed.")
+	}
+
+	if err := canvas.ConvertToProfile(nil, PERCEPTUAL_INTENT); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+}
+
+func testASCIIEntry(tag uint16, value string) exifEntry {
+	raw := append([]byte(value), 0)
+	return exifEntry{tag: tag, typ: exifASCII, count: uint32(len(raw)), value: raw}
+}
+
+func testShortEntry(tag uint16, value uint16) exifEntry {
+	raw := make([]byte, 2)
+	binary.LittleEndian.PutUint16(raw, value)
+	return exifEntry{tag: tag, typ: exifShort, count: 1, value: raw}
+}
+
+func testRationalEntry(tag uint16, values ...uint32) exifEntry {
+	raw := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(raw[4*i:], v)
+	}
+	return exifEntry{tag: tag, typ: exifRational, count: uint32(len(values) / 2), value: raw}
+}
+
+// Returns a JPEG EXIF profile with camera, orientation, GPS and serial number
+// tags.
+func testExif(orientation uint16) []byte {
+	gps := &exifIFD{entries: []exifEntry{
+		testASCIIEntry(0x01, "N"),
+		testRationalEntry(0x02, 40, 1, 26, 1, 4632, 100),
+		testASCIIEntry(0x03, "W"),
+		testRationalEntry(0x04, 79, 1, 58, 1, 5604, 100),
+		testRationalEntry(0x0d, 60, 1),
+	}}
+
+	sub := &exifIFD{entries: []exifEntry{
+		testASCIIEntry(0x9003, "2012:12:21 13:14:15"),
+		testRationalEntry(0x829a, 1, 250),
+		testShortEntry(0x8827, 200),
+		testASCIIEntry(0xa431, "SN-0123456789"),
+		testASCIIEntry(0xa434, "Canvas 50mm"),
+		testASCIIEntry(0xa420, "0123456789abcdef"),
+		testASCIIEntry(0xa430, "gosexy"),
+		{tag: exifTagMakerNote, typ: exifUndefined, count: 8, value: []byte("SN-01234"), offset: 1024},
+	}}
+
+	exif := &exifData{
+		order:  binary.LittleEndian,
+		header: true,
+		ifd0: &exifIFD{entries: []exifEntry{
+			testASCIIEntry(0x010f, "Canvas"),
+			testASCIIEntry(0x0110, "Test Camera"),
+			testShortEntry(exifTagOrientation, orientation),
+			testASCIIEntry(exifTagCopyright, "gosexy"),
+			{tag: exifTagExifIFD, typ: exifLong, count: 1, value: make([]byte, 4), sub: sub},
+			{tag: exifTagGPSIFD, typ: exifLong, count: 1, value: make([]byte, 4), sub: gps},
+		}},
+	}
+
+	return exif.encode()
+}
+
+// Opens example.jpg with the EXIF profile of testExif().
+func openTestExif(t *testing.T, orientation uint16) *Canvas {
+	canvas := New()
+
+	if err := canvas.Open("_examples/input/example.jpg"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.SetProfile("exif", testExif(orientation)); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if _, err := canvas.WriteToFormat(buf, "JPEG"); err != nil {
+		t.Fatalf("Error: %s\n", err)
 	}
 
 	canvas.Destroy()
+
+	canvas = New()
+
+	if _, err := canvas.ReadFrom(buf); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	return canvas
+}
+
+func TestExifMakerNote(t *testing.T) {
+	makerNote := make([]byte, 32)
+	for i := range makerNote {
+		makerNote[i] = byte(i)
+	}
+
+	thumbnail := []byte{0xff, 0xd8, 0xff, 0xd9}
+
+	exif := &exifData{
+		order:  binary.LittleEndian,
+		header: true,
+		ifd0: &exifIFD{entries: []exifEntry{
+			testASCIIEntry(0x010f, "Canvas"),
+			testShortEntry(exifTagOrientation, 6),
+			{tag: exifTagExifIFD, typ: exifLong, count: 1, value: make([]byte, 4), sub: &exifIFD{entries: []exifEntry{
+				{tag: exifTagMakerNote, typ: exifUndefined, count: uint32(len(makerNote)), value: makerNote, offset: 400},
+			}}},
+		}},
+		ifd1: &exifIFD{entries: []exifEntry{
+			{tag: exifTagThumbnailOffset, typ: exifLong, count: 1, value: make([]byte, 4)},
+			{tag: exifTagThumbnailLength, typ: exifLong, count: 1, value: []byte{byte(len(thumbnail)), 0, 0, 0}},
+		}},
+		thumbnail: thumbnail,
+	}
+
+	parsed, err := parseExif(exif.encode())
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	// Rewritten without a tag, as RemoveMetadata() does.
+	parsed.ifd0.filter(func(ifdTag uint16, tag uint16) bool {
+		return tag == 0x010f
+	}, 0)
+
+	parsed, err = parseExif(parsed.encode())
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	entry := parsed.ifd0.lookup("MakerNote", 0)
+
+	if entry == nil {
+		t.Fatalf("MakerNote should have been kept.")
+	}
+
+	if entry.offset != 400 || !bytes.Equal(entry.value, makerNote) {
+		t.Errorf("Got MakerNote at %d (% x), expecting it at 400.", entry.offset, entry.value)
+	}
+
+	if !bytes.Equal(parsed.thumbnail, thumbnail) {
+		t.Errorf("Got thumbnail % x, expecting % x.", parsed.thumbnail, thumbnail)
+	}
+
+	if parsed.ifd0.entry(0x010f) != nil || parsed.number("Orientation") != 6 {
+		t.Errorf("Unexpected IFD0 entries: %v", parsed.ifd0.entries)
+	}
+
+	// No room left at the original offset.
+	entry.offset = 16
+
+	parsed, err = parseExif(parsed.encode())
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if parsed.ifd0.lookup("MakerNote", 0) != nil {
+		t.Errorf("MakerNote should have been dropped.")
+	}
+
+	if parsed.number("Orientation") != 6 {
+		t.Errorf("Got %v, expecting %d.", parsed.number("Orientation"), 6)
+	}
+}
+
+func TestExifBrokenSubIFD(t *testing.T) {
+	data := testExif(1)
+
+	exif, err := parseExif(data)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	// Truncates the profile in the middle of the Exif IFD.
+	offset := exif.order.Uint32(exif.ifd0.entry(exifTagExifIFD).value)
+	data = data[:len(exifHeader)+int(offset)+6]
+
+	exif, err = parseExif(data)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if exif.ifd0.entry(exifTagExifIFD) != nil {
+		t.Errorf("Broken Exif IFD pointer should have been dropped.")
+	}
+
+	exif, err = parseExif(exif.encode())
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if exif.ifd0.entry(exifTagExifIFD) != nil || exif.text("Make") != "Canvas" {
+		t.Errorf("Unexpected IFD0 entries: %v", exif.ifd0.entries)
+	}
+}
+
+func TestStripWithOptions(t *testing.T) {
+	canvas := openTestExif(t, 6)
+	defer canvas.Destroy()
+
+	if canvas.Metadata()["exif:Make"] != "Canvas" {
+		t.Fatalf("Unexpected metadata: %v", canvas.Metadata())
+	}
+
+	err := canvas.StripWithOptions(StripOptions{
+		KeepOrientation: true,
+		KeepEXIFTags:    []string{"DateTimeOriginal"},
+	})
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	exif, err := parseExif(canvas.Profile("exif"))
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	names := map[string]bool{}
+	exif.ifd0.propertyNames(0, names)
+
+	for _, name := range []string{"exif:Orientation", "exif:DateTimeOriginal"} {
+		if !names[name] {
+			t.Errorf("%s should have been kept.", name)
+		}
+	}
+
+	for _, name := range []string{"exif:Make", "exif:Copyright", "exif:GPSLatitude", "exif:BodySerialNumber"} {
+		if names[name] {
+			t.Errorf("%s should have been removed.", name)
+		}
+		if _, found := canvas.Metadata()[name]; found {
+			t.Errorf("%s should have been removed from metadata.", name)
+		}
+	}
+
+	canvas.Write("_examples/output/example-strip-options.jpg")
+}
+
+func TestRemoveMetadata(t *testing.T) {
+	canvas := openTestExif(t, 1)
+	defer canvas.Destroy()
+
+	if err := canvas.RemoveMetadata("exif:GPS*", "exif:*SerialNumber"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	exif, err := parseExif(canvas.Profile("exif"))
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	names := map[string]bool{}
+	exif.ifd0.propertyNames(0, names)
+
+	if !names["exif:Make"] || !names["exif:LensModel"] {
+		t.Errorf("Unexpected tags: %v", names)
+	}
+
+	if names["exif:GPSInfo"] || names["exif:GPSLatitude"] || names["exif:BodySerialNumber"] {
+		t.Errorf("Unexpected tags: %v", names)
+	}
+
+	for key := range canvas.Metadata() {
+		if matchAny([]string{"exif:GPS*", "exif:*SerialNumber"}, key) {
+			t.Errorf("%s should have been removed from metadata.", key)
+		}
+	}
+}
+
+func TestRemoveMetadataTags(t *testing.T) {
+	canvas := openTestExif(t, 1)
+	defer canvas.Destroy()
+
+	if err := canvas.RemoveMetadata("exif:GPSSpeed", "exif:ImageUniqueID", "exif:CameraOwnerName"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	exif, err := parseExif(canvas.Profile("exif"))
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	names := map[string]bool{}
+	exif.ifd0.propertyNames(0, names)
+
+	if names["exif:GPSSpeed"] || names["exif:ImageUniqueID"] || names["exif:CameraOwnerName"] {
+		t.Errorf("Unexpected tags: %v", names)
+	}
+
+	if !names["exif:GPSLatitude"] || !names["exif:MakerNote"] {
+		t.Errorf("Unexpected tags: %v", names)
+	}
+
+	// Serial numbers may be stored in the MakerNote too.
+	if err := canvas.RemoveMetadata("exif:*SerialNumber"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	exif, err = parseExif(canvas.Profile("exif"))
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if exif.ifd0.lookup("MakerNote", 0) != nil {
+		t.Errorf("MakerNote should have been removed.")
+	}
+
+	if err := canvas.SetMetadata("exif:Unheard", "1"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.RemoveMetadata("exif:*"); err == nil {
+		t.Errorf("Expecting an error for an unknown EXIF tag.")
+	}
+
+	if canvas.Metadata()["exif:Make"] != "Canvas" {
+		t.Errorf("Nothing should have been removed.")
+	}
+}
+
+func TestEXIF(t *testing.T) {
+	canvas := openTestExif(t, 6)
+	defer canvas.Destroy()
+
+	exif, err := canvas.EXIF()
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if exif.Make != "Canvas" || exif.Model != "Test Camera" || exif.LensModel != "Canvas 50mm" {
+		t.Errorf("Unexpected camera: %s %s %s", exif.Make, exif.Model, exif.LensModel)
+	}
+
+	if exif.Orientation != RIGHT_TOP_ORIENTATION {
+		t.Errorf("Got %d, expecting %d.", exif.Orientation, RIGHT_TOP_ORIENTATION)
+	}
+
+	expected := time.Date(2012, 12, 21, 13, 14, 15, 0, time.UTC)
+
+	if !exif.DateTimeOriginal.Equal(expected) {
+		t.Errorf("Got %v, expecting %v.", exif.DateTimeOriginal, expected)
+	}
+
+	if exif.ExposureTime != 1.0/250 || exif.ISO != 200 {
+		t.Errorf("Unexpected exposure: %v s, ISO %d", exif.ExposureTime, exif.ISO)
+	}
+
+	if !exif.HasGPS || math.Abs(exif.Latitude-40.4462) > 1e-6 || math.Abs(exif.Longitude+79.982233) > 1e-6 {
+		t.Errorf("Unexpected coordinates: %v, %v", exif.Latitude, exif.Longitude)
+	}
+
+	blank := New()
+	defer blank.Destroy()
+
+	blank.Blank(10, 10)
+
+	if exif, err := blank.EXIF(); err != nil || exif.HasGPS || exif.Make != "" {
+		t.Errorf("Expecting an empty EXIF, got %v (%v).", exif, err)
+	}
+}
+
+func TestIPTC(t *testing.T) {
+	var iptc []byte
+
+	for _, dataset := range []struct {
+		key   uint16
+		value string
+	}{
+		{0x015a, "\x1b%G"},
+		{0x0205, "Sunset"},
+		{0x0219, "beach"},
+		{0x0219, "sea"},
+		{0x0237, "20121221"},
+		{0x023c, "131415+0100"},
+		{0x0250, "José"},
+		{0x025a, "Lisbon"},
+		{0x0278, "A sunset over the sea."},
+	} {
+		iptc = append(iptc, iptcMarker, byte(dataset.key>>8), byte(dataset.key), 0, byte(len(dataset.value)))
+		iptc = append(iptc, dataset.value...)
+	}
+
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.Blank(10, 10)
+
+	if err := canvas.SetProfile("iptc", iptc); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	info := canvas.IPTC()
+
+	if info.ObjectName != "Sunset" || info.City != "Lisbon" || info.Caption != "A sunset over the sea." {
+		t.Errorf("Unexpected IPTC: %v", info)
+	}
+
+	if strings.Join(info.Keywords, ",") != "beach,sea" || len(info.Byline) != 1 || info.Byline[0] != "José" {
+		t.Errorf("Unexpected IPTC: %v", info)
+	}
+
+	expected := time.Date(2012, 12, 21, 12, 14, 15, 0, time.UTC)
+
+	if !info.DateCreated.Equal(expected) {
+		t.Errorf("Got %v, expecting %v.", info.DateCreated, expected)
+	}
+}
+
+func TestXMP(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.Blank(10, 10)
+
+	if canvas.XMP() != nil {
+		t.Errorf("Expecting no XMP packet.")
+	}
+
+	packet := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"/></x:xmpmeta>`)
+
+	if err := canvas.SetXMP(packet); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if !bytes.Equal(canvas.XMP(), packet) {
+		t.Errorf("Got %s, expecting %s.", canvas.XMP(), packet)
+	}
+
+	if canvas.SetXMP([]byte("<x:xmpmeta>")) == nil {
+		t.Errorf("Expecting an error for a malformed packet.")
+	}
+}
+
+// Returns a 3x2 canvas whose pixels have distinct red values, stored with
+// the given orientation.
+func orientationFixture(t *testing.T, orientation uint) *Canvas {
+	canvas := New()
+
+	if err := canvas.Blank(3, 2); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.ImportPixels(0, 0, 3, 2, "R", []uint8{0, 40, 80, 120, 160, 200}); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if orientation != UNDEFINED_ORIENTATION {
+		if err := canvas.SetOrientation(orientation); err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+	}
+
+	canvas.Write(fmt.Sprintf("_examples/output/orientation-%d.png", orientation))
+
+	return canvas
+}
+
+func TestAutoOrientate(t *testing.T) {
+	// stored[y][x] of the fixture, and where each displayed pixel comes from.
+	stored := [2][3]uint8{{0, 40, 80}, {120, 160, 200}}
+
+	source := map[uint]func(x, y int) uint8{
+		TOP_LEFT_ORIENTATION:     func(x, y int) uint8 { return stored[y][x] },
+		TOP_RIGHT_ORIENTATION:    func(x, y int) uint8 { return stored[y][2-x] },
+		BOTTOM_RIGHT_ORIENTATION: func(x, y int) uint8 { return stored[1-y][2-x] },
+		BOTTOM_LEFT_ORIENTATION:  func(x, y int) uint8 { return stored[1-y][x] },
+		LEFT_TOP_ORIENTATION:     func(x, y int) uint8 { return stored[x][y] },
+		RIGHT_TOP_ORIENTATION:    func(x, y int) uint8 { return stored[1-x][y] },
+		RIGHT_BOTTOM_ORIENTATION: func(x, y int) uint8 { return stored[1-x][2-y] },
+		LEFT_BOTTOM_ORIENTATION:  func(x, y int) uint8 { return stored[x][2-y] },
+	}
+
+	for orientation := TOP_LEFT_ORIENTATION; orientation <= LEFT_BOTTOM_ORIENTATION; orientation++ {
+		canvas := orientationFixture(t, orientation)
+
+		if canvas.Orientation() != orientation {
+			t.Errorf("Got %d, expecting %d.", canvas.Orientation(), orientation)
+		}
+
+		if err := canvas.AutoOrientate(); err != nil {
+			t.Errorf("Error: %s\n", err)
+		}
+
+		width, height := 3, 2
+		if orientation >= LEFT_TOP_ORIENTATION {
+			width, height = 2, 3
+		}
+
+		if canvas.Width() != uint(width) || canvas.Height() != uint(height) {
+			t.Errorf("Orientation %d: got %dx%d, expecting %dx%d.", orientation, canvas.Width(), canvas.Height(), width, height)
+			canvas.Destroy()
+			continue
+		}
+
+		pixels, err := canvas.ExportPixels(0, 0, uint(width), uint(height), "R", CharStorage)
+
+		if err != nil {
+			t.Errorf("Error: %s\n", err)
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				got, expected := pixels.([]uint8)[y*width+x], source[orientation](x, y)
+				if got != expected {
+					t.Errorf("Orientation %d: got %d at (%d, %d), expecting %d.", orientation, got, x, y, expected)
+				}
+			}
+		}
+
+		if canvas.Orientation() != TOP_LEFT_ORIENTATION {
+			t.Errorf("Got %d, expecting %d.", canvas.Orientation(), TOP_LEFT_ORIENTATION)
+		}
+
+		if canvas.Metadata()["exif:Orientation"] != "1" {
+			t.Errorf("Got %q, expecting %q.", canvas.Metadata()["exif:Orientation"], "1")
+		}
+
+		canvas.Destroy()
+	}
+}
+
+func TestAutoOrientateUndefined(t *testing.T) {
+	canvas := orientationFixture(t, UNDEFINED_ORIENTATION)
+	defer canvas.Destroy()
+
+	if canvas.Orientation() != UNDEFINED_ORIENTATION {
+		t.Errorf("Got %d, expecting %d.", canvas.Orientation(), UNDEFINED_ORIENTATION)
+	}
+
+	if err := canvas.AutoOrientate(); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if canvas.Width() != 3 || canvas.Height() != 2 {
+		t.Errorf("Got %dx%d, expecting 3x2.", canvas.Width(), canvas.Height())
+	}
+
+	if canvas.SetOrientation(9) == nil {
+		t.Errorf("Expecting an error for an invalid orientation.")
+	}
+}
+
+func TestAutoOrientateFrames(t *testing.T) {
+	canvas := orientationFixture(t, RIGHT_TOP_ORIENTATION)
+	defer canvas.Destroy()
+
+	frame := orientationFixture(t, RIGHT_TOP_ORIENTATION)
+	defer frame.Destroy()
+
+	if err := canvas.AddFrame(frame); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.AutoOrientate(); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	for i := uint(0); i < canvas.Frames(); i++ {
+		canvas.SetFrame(i)
+		if canvas.Width() != 2 || canvas.Height() != 3 {
+			t.Errorf("Frame %d: got %dx%d, expecting 2x3.", i, canvas.Width(), canvas.Height())
+		}
+	}
+}
+
+func TestAutoOrientateEXIF(t *testing.T) {
+	canvas := openTestExif(t, 8)
+	defer canvas.Destroy()
+
+	width, height := canvas.Width(), canvas.Height()
+
+	if err := canvas.AutoOrientate(); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if canvas.Width() != height || canvas.Height() != width {
+		t.Errorf("Got %dx%d, expecting %dx%d.", canvas.Width(), canvas.Height(), height, width)
+	}
+
+	exif, err := canvas.EXIF()
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if exif.Orientation != TOP_LEFT_ORIENTATION {
+		t.Errorf("Got %d, expecting %d.", exif.Orientation, TOP_LEFT_ORIENTATION)
+	}
+
+	canvas.Write("_examples/output/example-autoorientate.jpg")
+}
+
+func TestProbe(t *testing.T) {
+	info, err := Probe("_examples/input/example.png")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	canvas := New()
+	defer canvas.Destroy()
+
+	if err = canvas.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if info.Format != "PNG" {
+		t.Errorf("Got %s, expecting %s.", info.Format, "PNG")
+	}
+
+	if info.Width != canvas.Width() || info.Height != canvas.Height() {
+		t.Errorf("Got %dx%d, expecting %dx%d.", info.Width, info.Height, canvas.Width(), canvas.Height())
+	}
+
+	if info.Frames != 1 || info.FileSize == 0 {
+		t.Errorf("Unexpected probe result: %v", info)
+	}
+
+	file, err := os.Open("_examples/input/example.jpg")
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	defer file.Close()
+
+	if info, err = ProbeReader(file); err != nil || info.Format != "JPEG" {
+		t.Errorf("Could not probe reader: %v, %v", info, err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.png")
+
+	if err == nil {
+		canvas.AutoOrientate()
+
+		canvas.Thumbnail(100, 100)
+
+		canvas.Write("_examples/output/example-thumbnail.png")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestFit(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.png")
+
+	if err == nil {
+		canvas.AutoOrientate()
+
+		canvas.Fit(100, 100)
+		
+		canvas.Write("_examples/output/example-fit.png")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestFrames(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	colors := []string{"#ff0000", "#00ff00", "#0000ff"}
+
+	for _, color := range colors {
+		frame := New()
+		frame.SetBackgroundColor(color)
+		frame.Blank(200, 100)
+		frame.SetDelay(50)
+
+		if err := canvas.AddFrame(frame); err != nil {
+			t.Errorf("Error: %s\n", err)
+		}
+
+		frame.Destroy()
+	}
+
+	if frames := canvas.Frames(); frames != uint(len(colors)) {
+		t.Fatalf("Got %d, expecting %d.", frames, len(colors))
+	}
+
+	if err := canvas.MoveFrame(2, 0); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.SetLoop(0)
+	canvas.SetFormat("GIF")
+
+	if err := canvas.Thumbnail(50, 50); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	for i := uint(0); i < canvas.Frames(); i++ {
+		frame, err := canvas.Frame(i)
+		if err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+
+		if frame.Width() != 50 || frame.Height() != 50 {
+			t.Errorf("Got %dx%d, expecting 50x50.", frame.Width(), frame.Height())
+		}
+
+		frame.Destroy()
+	}
+
+	if err := canvas.RemoveFrame(1); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if frames := canvas.Frames(); frames != uint(len(colors)-1) {
+		t.Errorf("Got %d, expecting %d.", frames, len(colors)-1)
+	}
+
+	canvas.Write("_examples/output/example-animation.gif")
+
+	if err := canvas.Coalesce(); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	blob, err := canvas.Blob()
+
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	decoded := New()
+	defer decoded.Destroy()
+
+	if _, err := decoded.ReadFrom(bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if decoded.Frames() != canvas.Frames() {
+		t.Errorf("Got %d, expecting %d.", decoded.Frames(), canvas.Frames())
+	}
+}
+
+// Returns a canvas with a 10x10 frame of each color.
+func testFrames(t *testing.T, colors ...string) *Canvas {
+	canvas := New()
+
+	for _, color := range colors {
+		frame := New()
+		frame.SetBackgroundColor(color)
+		frame.Blank(10, 10)
+
+		if err := canvas.AddFrame(frame); err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+
+		frame.Destroy()
+	}
+
+	return canvas
+}
+
+// Returns the color of the top left pixel of every frame.
+func frameColors(t *testing.T, canvas *Canvas) []string {
+	var colors []string
+
+	for i := uint(0); i < canvas.Frames(); i++ {
+		frame, err := canvas.Frame(i)
+		if err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+
+		rgb, err := frame.ExportPixels8(0, 0, 1, 1, "RGB")
+		if err != nil {
+			t.Fatalf("Error: %s\n", err)
+		}
+
+		colors = append(colors, fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+
+		frame.Destroy()
+	}
+
+	return colors
+}
+
+func TestMoveFrame(t *testing.T) {
+	tests := []struct {
+		colors   []string
+		from, to uint
+		expected []string
+	}{
+		{[]string{"#ff0000", "#00ff00", "#0000ff"}, 2, 0, []string{"#0000ff", "#ff0000", "#00ff00"}},
+		{[]string{"#ff0000", "#00ff00", "#0000ff"}, 0, 2, []string{"#00ff00", "#0000ff", "#ff0000"}},
+		{[]string{"#ff0000", "#00ff00", "#0000ff"}, 1, 2, []string{"#ff0000", "#0000ff", "#00ff00"}},
+		{[]string{"#ff0000", "#00ff00"}, 1, 0, []string{"#00ff00", "#ff0000"}},
+	}
+
+	for _, test := range tests {
+		canvas := testFrames(t, test.colors...)
+
+		if err := canvas.MoveFrame(test.from, test.to); err != nil {
+			t.Errorf("Error: %s\n", err)
+		}
+
+		got := frameColors(t, canvas)
+
+		if strings.Join(got, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("MoveFrame(%d, %d): got %v, expecting %v.", test.from, test.to, got, test.expected)
+		}
+
+		if canvas.CurrentFrame() != test.to {
+			t.Errorf("Got %d, expecting %d.", canvas.CurrentFrame(), test.to)
+		}
+
+		canvas.Destroy()
+	}
+}
+
+func TestThumbnailPages(t *testing.T) {
+	pages := New()
+
+	for _, page := range []struct {
+		color         string
+		width, height uint
+	}{
+		{"#ff0000", 100, 50},
+		{"#0000ff", 40, 80},
+	} {
+		frame := New()
+		frame.SetBackgroundColor(page.color)
+		frame.Blank(page.width, page.height)
+		pages.AddFrame(frame)
+		frame.Destroy()
+	}
+
+	buf := &bytes.Buffer{}
+
+	if _, err := pages.WriteToFormat(buf, "TIFF"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	pages.Destroy()
+
+	canvas := New()
+	defer canvas.Destroy()
+
+	if _, err := canvas.ReadFrom(buf); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if err := canvas.Fit(20, 20); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	expected := []struct{ width, height uint }{{20, 10}, {10, 20}}
+
+	if canvas.Frames() != uint(len(expected)) {
+		t.Fatalf("Got %d, expecting %d.", canvas.Frames(), len(expected))
+	}
+
+	for i, size := range expected {
+		canvas.SetFrame(uint(i))
+		if canvas.Width() != size.width || canvas.Height() != size.height {
+			t.Errorf("Page %d: got %dx%d, expecting %dx%d.", i, canvas.Width(), canvas.Height(), size.width, size.height)
+		}
+	}
+
+	if colors := frameColors(t, canvas); strings.Join(colors, ",") != "#ff0000,#0000ff" {
+		t.Errorf("Got %v, expecting [#ff0000 #0000ff].", colors)
+	}
+}
+
+// https://github.com/gosexy/canvas/issues/3
+func TestThumbnailIssue3(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.png")
+
+	if err == nil {
+		canvas.AutoOrientate()
+
+		canvas.Thumbnail(2000, 2000)
+
+		canvas.Write("_examples/output/example-bigger-than-original-thumbnail.png")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestClone(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.png")
+
+	if err == nil {
+
+		clone := canvas.Clone()
+		clone.Resize(100, 100)
+		clone.Write("_examples/output/cloned-100x100.png")
+
+		clone.Destroy()
+
+		canvas.Write("_examples/output/not-cloned.png")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestResize(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.png")
+
+	if err == nil {
+		canvas.Resize(100, 100)
+		canvas.Write("_examples/output/example-100x100.png")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestResizeWithFilter(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.jpg")
+
+	if err == nil {
+		canvas.ResizeWithFilter(170, 0, HANNING_FILTER, 5.0)
+		canvas.Write("_examples/output/example-hanning-filter.jpg")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestSharpenImage(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.jpg")
+
+	if err == nil {
+		canvas.SharpenImage(1.0, 1.0, 0)
+		canvas.Write("_examples/output/example-sharpen-image.jpg")
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestGetImageBlob(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Open("_examples/input/example.jpg")
+
+	if err == nil {
+		if blob, err := canvas.GetImageBlob(); err != nil || len(blob) == 0 {
+			t.Errorf("Error: can not get image blob")
+		}
+	} else {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Destroy()
+}
+
+func TestBlank(t *testing.T) {
+	canvas := New()
+
+	canvas.SetBackgroundColor("#00ff00")
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+		canvas.Write("_examples/output/example-blank.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+
+	canvas.Destroy()
+}
+
+func TestSettersAndGetters(t *testing.T) {
+
+	canvas := New()
+
+	err := canvas.Blank(400, 400)
+
+	if err != nil {
+		t.Errorf("Could not create blank image.")
+	}
+
+	const backgroundColor = "#112233"
+
+	canvas.SetBackgroundColor(backgroundColor)
+
+	if gotBackgroundColor := canvas.BackgroundColor(); gotBackgroundColor != backgroundColor {
+		t.Errorf("Got %s, expecting %s", gotBackgroundColor, backgroundColor)
+	}
+
+	const strokeAntialias = true
+
+	canvas.SetStrokeAntialias(strokeAntialias)
+
+	if gotStrokeAntialias := canvas.StrokeAntialias(); gotStrokeAntialias != strokeAntialias {
+		t.Errorf("Got %t, expecting %t.", gotStrokeAntialias, strokeAntialias)
+	}
+
+	const strokeWidth = 2.0
+
+	canvas.SetStrokeWidth(strokeWidth)
+
+	if gotStrokeWidth := canvas.StrokeWidth(); gotStrokeWidth != strokeWidth {
+		t.Errorf("Got %f, expecting %f.", gotStrokeWidth, strokeWidth)
+	}
+
+	const strokeOpacity = 1.0
+
+	canvas.SetStrokeOpacity(strokeOpacity)
+
+	if gotStrokeOpacity := canvas.StrokeOpacity(); gotStrokeOpacity != strokeOpacity {
+		t.Errorf("Got %f, expecting %f.", gotStrokeOpacity, strokeOpacity)
+	}
+
+	strokeLineCap := STROKE_SQUARE_CAP
+
+	canvas.SetStrokeLineCap(strokeLineCap)
+
+	if gotStrokeLineCap := canvas.StrokeLineCap(); gotStrokeLineCap != strokeLineCap {
+		t.Errorf("Got %d, expecting %d.", gotStrokeLineCap, strokeLineCap)
+	}
+
+	strokeLineJoin := STROKE_ROUND_JOIN
+
+	canvas.SetStrokeLineJoin(strokeLineJoin)
+
+	if gotStrokeLineJoin := canvas.StrokeLineJoin(); gotStrokeLineJoin != strokeLineJoin {
+		t.Errorf("Got %d, expecting %d.", gotStrokeLineJoin, strokeLineJoin)
+	}
+
+	const fillColor = "#112233"
+
+	canvas.SetFillColor(fillColor)
+
+	if gotFillColor := canvas.FillColor(); gotFillColor != fillColor {
+		t.Errorf("Got %s, expecting %s", gotFillColor, fillColor)
+	}
+
+	const strokeColor = "#112233"
+
+	canvas.SetStrokeColor(strokeColor)
+
+	if gotStrokeColor := canvas.StrokeColor(); gotStrokeColor != strokeColor {
+		t.Errorf("Got %s, expecting %s", gotStrokeColor, strokeColor)
+	}
+
+	const quality = 76
+
+	canvas.SetQuality(quality)
+
+	if gotQuality := canvas.Quality(); gotQuality != quality {
+		t.Errorf("Got %d, expecting %d", gotQuality, quality)
+	}
+
+	const interlaceScheme = NO_INTERLACE
+
+	canvas.SetInterlaceScheme(interlaceScheme)
+
+	if gotInterlaceScheme := canvas.InterlaceScheme(); interlaceScheme != interlaceScheme {
+		t.Errorf("Got %d, expecting %d", gotInterlaceScheme, interlaceScheme)
+	}
+
+	canvas.Destroy()
+}
+
+func TestDrawLine(t *testing.T) {
+
+	canvas := New()
+
+	canvas.SetBackgroundColor("#000000")
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+
+		canvas.Translate(200, 200)
+		canvas.SetStrokeWidth(10)
+		canvas.SetStrokeColor("#ffffff")
+		canvas.Line(100, 100)
+
+		canvas.Write("_examples/output/example-line.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+
+	canvas.Destroy()
+}
+
+func TestDrawCircle(t *testing.T) {
+	canvas := New()
+
+	canvas.SetBackgroundColor("#000000")
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+
+		canvas.SetFillColor("#ff0000")
+
+		canvas.PushDrawing()
+		canvas.Translate(200, 200)
+		canvas.SetStrokeWidth(5)
+		canvas.SetStrokeColor("#ffffff")
+		canvas.Circle(100)
+		canvas.PopDrawing()
+
+		canvas.PushDrawing()
+		canvas.Translate(100, 100)
+		canvas.SetStrokeWidth(3)
+		canvas.SetStrokeColor("#ffffff")
+		canvas.Circle(20)
+		canvas.PopDrawing()
+
+		canvas.Write("_examples/output/example-circle.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+
+	canvas.Destroy()
+}
+
+func TestDrawRectangle(t *testing.T) {
+	canvas := New()
+
+	canvas.SetBackgroundColor("#000000")
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+
+		canvas.SetFillColor("#ff0000")
+
+		canvas.Translate(200-50, 200+75)
+		canvas.SetStrokeWidth(5)
+		canvas.SetStrokeColor("#ffffff")
+		canvas.Rectangle(100, -150)
+
+		canvas.Write("_examples/output/example-rectangle.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+
+	canvas.Destroy()
+}
+
+func TestDrawEllipse(t *testing.T) {
+	canvas := New()
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+
+		canvas.SetFillColor("#ff0000")
+
+		canvas.PushDrawing()
+		canvas.Translate(200, 200)
+		canvas.Rotate(math.Pi / 3)
+		canvas.Ellipse(50, 180)
+		canvas.PopDrawing()
+
+		canvas.SetFillColor("#ff00ff")
+
+		canvas.PushDrawing()
+		canvas.Translate(200, 200)
+		canvas.Rotate(-math.Pi / 3)
+		canvas.Ellipse(25, 90)
+		canvas.PopDrawing()
+
+		canvas.Write("_examples/output/example-ellipse.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+
+	canvas.Destroy()
+}
+
+func TestDrawPath(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetBackgroundColor("#000000")
+
+	err := canvas.Blank(400, 400)
+
+	if err == nil {
+		canvas.SetStrokeColor("#ffffff")
+		canvas.SetStrokeWidth(3)
+		canvas.SetFillColor("#ff0000")
+
+		canvas.PathStart()
+		canvas.MoveTo(50, 350)
+		canvas.LineTo(50, 200)
+		canvas.CurveTo(50, 100, 150, 50, 200, 50)
+		canvas.QuadTo(350, 50, 350, 200)
+		canvas.ArcTo(75, 75, 0, false, true, 200, 350)
+		canvas.ClosePath()
+		canvas.PathFinish()
+
+		canvas.SetFillColor("#00ff00")
+		canvas.SetFillRule(FILL_EVEN_ODD_RULE)
+		canvas.Polygon([]Point{{100, 100}, {300, 100}, {200, 300}})
+
+		canvas.SetFillColor("none")
+		canvas.Polyline([]Point{{10, 390}, {100, 300}, {200, 390}, {390, 10}})
+		canvas.Bezier([]Point{{10, 10}, {200, 200}, {390, 10}})
+
+		canvas.PushDrawing()
+		canvas.Translate(20, 20)
+		canvas.RoundRectangle(100, 60, 10, 10)
+		canvas.PopDrawing()
+
+		canvas.Write("_examples/output/example-path.png")
+	} else {
+		t.Errorf("Failed to create blank image.")
+	}
+}
+
+// Checks the RGBA values of the pixel at (x, y), give or take 2.
+func testPixel(t *testing.T, canvas *Canvas, x int, y int, expected ...uint8) {
+	pixel, err := canvas.ExportPixels8(x, y, 1, 1, "RGBA")
+
+	if err != nil {
+		t.Errorf("Error: %s\n", err)
+		return
+	}
+
+	for i := range expected {
+		if d := int(pixel[i]) - int(expected[i]); d < -2 || d > 2 {
+			t.Errorf("Got %v at (%d, %d), expecting %v.", pixel, x, y, expected)
+			return
+		}
+	}
+}
+
+func TestPaint(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	background := NewLinearGradient(0, 0, 0, 400)
+	background.AddStop(0, "#ff0000", 1)
+	background.AddStop(0.5, "#ffff00", 1)
+	background.AddStop(1, "#0000ff", 0.5)
+
+	if err := canvas.BlankWithPaint(400, 400, background); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	// Start, middle of the first segment and end of the gradient.
+	testPixel(t, canvas, 200, 0, 0xff, 0x01, 0x00, 0xff)
+	testPixel(t, canvas, 200, 100, 0xff, 0x80, 0x00, 0xff)
+	testPixel(t, canvas, 200, 399, 0x01, 0x01, 0xfe, 0x80)
+
+	radial := NewRadialGradient(200, 200, 100)
+	radial.AddStop(0, "#ffffff", 1)
+	radial.AddStop(1, "#000000", 0)
+
+	if err := canvas.SetFillPaint(radial); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.PushDrawing()
+	canvas.Translate(200, 200)
+	canvas.Circle(100)
+	canvas.PopDrawing()
+
+	tile := New()
+	defer tile.Destroy()
+
+	tile.SetBackgroundColor("#00ff00")
+	tile.Blank(20, 20)
+
+	if err := canvas.SetStrokePaint(NewPattern(tile)); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.SetStrokeWidth(10)
+	canvas.Line(400, 400)
+
+	if err := canvas.SetFillPaint(NewLinearGradient(0, 0, 1, 1)); err == nil {
+		t.Errorf("Gradient without stops should have failed.")
+	}
+
+	canvas.Write("_examples/output/example-paint.png")
+
+	bar := New()
+	defer bar.Destroy()
+
+	bar.Blank(100, 10)
+
+	gradient := NewLinearGradient(20, 0, 80, 0)
+	gradient.AddStop(0, "#ff0000", 1)
+	gradient.AddStop(1, "#0000ff", 1)
+
+	if err := bar.SetFillPaint(gradient); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	bar.SetStrokeColor("none")
+	bar.Rectangle(100, 10)
+	bar.Update()
+
+	// Before, inside and after the gradient line.
+	testPixel(t, bar, 5, 5, 0xff, 0x00, 0x00, 0xff)
+	testPixel(t, bar, 50, 5, 0x7d, 0x00, 0x82, 0xff)
+	testPixel(t, bar, 95, 5, 0x00, 0x00, 0xff, 0xff)
+}
+
+func TestClip(t *testing.T) {
+	photo := New()
+	defer photo.Destroy()
+
+	if err := photo.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	photo.Thumbnail(200, 200)
+
+	avatar := New()
+	defer avatar.Destroy()
+
+	avatar.Blank(200, 200)
+
+	path := NewClipPath()
+	path.Circle(100, 100, 100)
+
+	if err := avatar.SetClipPath(path); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	avatar.AppendCanvas(photo, 0, 0)
+	avatar.ClearClip()
+
+	// Outside of the circle.
+	if alpha, err := avatar.ExportPixels8(2, 2, 1, 1, "A"); err != nil || alpha[0] != 0 {
+		t.Errorf("Got alpha %v, expecting %d.", alpha, 0)
+	}
+
+	if alpha, err := avatar.ExportPixels8(100, 100, 1, 1, "A"); err != nil || alpha[0] != 0xff {
+		t.Errorf("Got alpha %v, expecting %d.", alpha, 0xff)
+	}
+
+	avatar.Write("_examples/output/example-avatar.png")
+
+	// Drawing queued before SetClipPath() isn't clipped, nor is drawing after
+	// ClearClip().
+	square := New()
+	defer square.Destroy()
+
+	square.Blank(20, 20)
+	square.SetStrokeColor("none")
+	square.SetFillColor("#ff0000")
+	square.Rectangle(5, 5)
+
+	path = NewClipPath()
+	path.Rectangle(10, 10, 10, 10)
+
+	if err := square.SetClipPath(path); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if rgba, err := square.ExportPixels8(2, 2, 1, 1, "RGBA"); err != nil || rgba[0] != 0xff || rgba[3] != 0xff {
+		t.Errorf("Got %v, expecting [255 0 0 255].", rgba)
+	}
+
+	square.ClearClip()
+	square.SetFillColor("#0000ff")
+	square.Rectangle(5, 5)
+	square.Update()
+
+	if rgba, err := square.ExportPixels8(2, 2, 1, 1, "RGBA"); err != nil || rgba[2] != 0xff || rgba[3] != 0xff {
+		t.Errorf("Got %v, expecting [0 0 255 255].", rgba)
+	}
+
+	mask := New()
+	defer mask.Destroy()
+
+	mask.SetBackgroundColor("#000000")
+	mask.Blank(200, 200)
+	mask.SetFillColor("#ffffff")
+	mask.PushDrawing()
+	mask.Translate(100, 100)
+	mask.Ellipse(100, 50)
+	mask.PopDrawing()
+	mask.Update()
+
+	if err := photo.SetClipMask(mask, LuminanceMask); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	photo.SetFillColor("#ff0000")
+	photo.Rectangle(200, 200)
+	photo.Write("_examples/output/example-clip-mask.png")
+
+	if err := photo.SetClipMask(avatar, AlphaMask); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	empty := New()
+	defer empty.Destroy()
+
+	if err := photo.SetClipMask(empty, AlphaMask); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+
+	blank := New()
+	defer blank.Destroy()
+
+	if err := blank.SetClipMask(empty, AlphaMask); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+}
+
+func TestComposite(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	if err := canvas.Open("_examples/input/example.png"); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	watermark := New()
+	defer watermark.Destroy()
+
+	watermark.SetBackgroundColor("#ffffff")
+	watermark.Blank(100, 50)
+
+	err := canvas.Composite(watermark, OVER_COMPOSITE, 10, 10, WithGravity(SOUTH_EAST_GRAVITY), WithOpacity(0.5))
+	if err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	tint := New()
+	defer tint.Destroy()
+
+	tint.SetBackgroundColor("#ff8800")
+	tint.Blank(canvas.Width(), canvas.Height())
+
+	if err = canvas.Composite(tint, MULTIPLY_COMPOSITE, 0, 0); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Write("_examples/output/example-composite.png")
+
+	// The watermark lands 10 pixels away from the bottom right corner, half
+	// blended with the background.
+	black := New()
+	defer black.Destroy()
+
+	black.SetBackgroundColor("#000000")
+	black.Blank(200, 100)
+
+	if err = black.Composite(watermark, OVER_COMPOSITE, 10, 10, WithGravity(SOUTH_EAST_GRAVITY), WithOpacity(0.5)); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	testPixel(t, black, 90, 40, 0x80, 0x80, 0x80, 0xff)
+	testPixel(t, black, 189, 89, 0x80, 0x80, 0x80, 0xff)
+	testPixel(t, black, 89, 40, 0x00, 0x00, 0x00, 0xff)
+	testPixel(t, black, 189, 90, 0x00, 0x00, 0x00, 0xff)
+	testPixel(t, black, 190, 89, 0x00, 0x00, 0x00, 0xff)
+
+	if x, y := gravityOffset(CENTER_GRAVITY, 100, 100, 20, 40, 5, -5); x != 45 || y != 25 {
+		t.Errorf("Got (%d, %d), expecting (45, 25).", x, y)
+	}
+
+	if x, y := gravityOffset(SOUTH_EAST_GRAVITY, 100, 100, 20, 40, 5, 5); x != 75 || y != 55 {
+		t.Errorf("Got (%d, %d), expecting (75, 55).", x, y)
+	}
+}
+
+func TestMeasureText(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetFontSize(20)
+
+	metrics, err := canvas.MeasureText("Hello\nWorld!", nil)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if len(metrics.Lines) != 2 {
+		t.Fatalf("Got %d lines, expecting 2.", len(metrics.Lines))
+	}
+
+	if metrics.Width <= 0 || metrics.Height <= metrics.Lines[0].Height {
+		t.Errorf("Unexpected metrics: %v", metrics)
+	}
+
+	prop := canvas.NewTextProperties(true)
+	prop.Size = 40
+
+	bigger, err := canvas.MeasureText("World!", prop)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if bigger.Width <= metrics.Lines[1].Width {
+		t.Errorf("Got %f, expecting more than %f.", bigger.Width, metrics.Lines[1].Width)
+	}
+
+	if canvas.FontSize() != 20 {
+		t.Errorf("Got %f, expecting %f.", canvas.FontSize(), 20.0)
+	}
+
+	blank, err := canvas.MeasureText("a\n\nb", nil)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if len(blank.Lines) != 3 {
+		t.Fatalf("Got %d lines, expecting 3.", len(blank.Lines))
+	}
+
+	if blank.Lines[1].Width != 0 || blank.Lines[1].Height != blank.Lines[0].Height {
+		t.Errorf("Got %v, expecting a blank line as high as %v.", blank.Lines[1], blank.Lines[0])
+	}
+}
+
+func TestTextProperties(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	prop := canvas.NewTextProperties(true)
+	prop.Style = ItalicStyle
+	prop.Decoration = UnderlineDecoration
+	prop.Stretch = CondensedStretch
+	prop.Interword = 12
+	prop.Interline = 4
+	prop.Gravity = CENTER_GRAVITY
+	prop.Resolution = [2]float64{144, 144}
+	prop.Encoding = "UTF-8"
+
+	canvas.SetTextProperties(prop)
+
+	read := canvas.NewTextProperties(true)
+
+	if read.Style != prop.Style || read.Decoration != prop.Decoration || read.Stretch != prop.Stretch {
+		t.Errorf("Got %v, expecting %v.", read, prop)
+	}
+
+	if read.Interword != prop.Interword || read.Interline != prop.Interline || read.Gravity != prop.Gravity {
+		t.Errorf("Got %v, expecting %v.", read, prop)
+	}
+
+	if read.Resolution != prop.Resolution || read.Encoding != prop.Encoding {
+		t.Errorf("Got %v, expecting %v.", read, prop)
+	}
+
+	// Undefined fields keep their current value.
+	next := *prop
+	next.Style = UndefinedStyle
+	next.Stretch = UndefinedStretch
+
+	canvas.SetTextProperties(&next)
+
+	if current := canvas.TextProperties(); current.Style != ItalicStyle || current.Stretch != CondensedStretch {
+		t.Errorf("Got %v, expecting %v.", current, prop)
+	}
+
+	if read := canvas.NewTextProperties(true); *read != *canvas.TextProperties() {
+		t.Errorf("Got %v, expecting %v.", read, canvas.TextProperties())
+	}
+
+	canvas.SetFontStyle(ObliqueStyle)
+
+	if next.Style != UndefinedStyle {
+		t.Errorf("Got %v, expecting %v.", next.Style, UndefinedStyle)
+	}
+
+	canvas.SetTextProperties(prop)
+
+	canvas.SetBackgroundColor("#ffffff")
+	canvas.Blank(400, 100)
+
+	canvas.Annotate("Hello World!", 0, 0)
+
+	canvas.Write("_examples/output/example-text-properties.png")
+}
+
+func TestTextPropertiesRestore(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetBackgroundColor("#ffffff")
+	canvas.Blank(400, 100)
+
+	original := canvas.NewTextProperties(true)
+
+	prop := canvas.NewTextProperties(true)
+	prop.Style = ItalicStyle
+	prop.Decoration = UnderlineDecoration
+	prop.Stretch = CondensedStretch
+	prop.Resolution = [2]float64{144, 144}
+	prop.Encoding = "UTF-8"
+	prop.UnderColor = "#ffff00"
+
+	if _, err := canvas.MeasureText("Hello World!", prop); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.AnnotateWithProperties("Hello World!", 10, 40, prop)
+
+	if err := canvas.DrawText("Hello World!", &TextBox{X: 10, Y: 50, Width: 300, Height: 40, Properties: prop}); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if read := canvas.NewTextProperties(true); *read != *original {
+		t.Errorf("Got %v, expecting %v.", read, original)
+	}
+
+	if read := canvas.TextProperties(); *read != *original {
+		t.Errorf("Got %v, expecting %v.", read, original)
+	}
+}
+
+func TestTextUnderColor(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetBackgroundColor("#ffffff")
+	canvas.Blank(300, 100)
+
+	prop := canvas.NewTextProperties(true)
+	prop.Size = 32
+	prop.SetUnderColor(color.NRGBA{0xff, 0xcc, 0x00, 0xff})
+	prop.UnderColorPadding = 8
+	prop.UnderColorRadius = 6
+
+	if prop.UnderColor != "#ffcc00ff" {
+		t.Errorf("Got %s, expecting %s.", prop.UnderColor, "#ffcc00ff")
+	}
+
+	canvas.SetTextProperties(prop)
+
+	read := canvas.NewTextProperties(true)
+
+	if read.UnderColor != prop.UnderColor || read.UnderColorPadding != 8 || read.UnderColorRadius != 6 {
+		t.Errorf("Got %v, expecting %v.", read, prop)
+	}
+
+	canvas.SetFillColor("#000000")
+	canvas.Annotate("Hello World!", 20, 60)
+
+	if err := canvas.SetTextUnderColor(""); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if err := canvas.SetTextUnderColor("not a color"); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+
+	invalid := canvas.NewTextProperties(true)
+	invalid.UnderColor = "not a color"
+	invalid.UnderColorPadding = 4
+
+	canvas.AnnotateWithProperties("Hello World!", 20, 90, invalid)
+
+	if err := canvas.Error(); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+
+	canvas.SetTextProperties(invalid)
+
+	if err := canvas.Error(); err == nil {
+		t.Errorf("Test should have failed.")
+	}
+
+	if canvas.TextUnderColor() != "" {
+		t.Errorf("Got %q, expecting %q.", canvas.TextUnderColor(), "")
+	}
+
+	canvas.Write("_examples/output/example-text-under-color.png")
+}
+
+func TestDrawText(t *testing.T) {
+	canvas := New()
+	defer canvas.Destroy()
+
+	canvas.SetFontSize(24)
+
+	text := "The quick brown fox jumps over the lazy dog"
+
+	layout, err := canvas.wrapText(text, 120, 1)
+	if err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if len(layout.lines) < 2 {
+		t.Fatalf("Got %d lines, expecting at least 2.", len(layout.lines))
+	}
+
+	for i, width := range layout.widths {
+		if width > 120 {
+			t.Errorf("Line %q is %f wide, expecting at most 120.", layout.lines[i], width)
+		}
+	}
+
+	if err := canvas.ellipsize(layout, 120, layout.lineHeight); err != nil {
+		t.Fatalf("Error: %s\n", err)
+	}
+
+	if len(layout.lines) != 1 || !strings.HasSuffix(layout.lines[0], ellipsis) {
+		t.Errorf("Unexpected lines: %q", layout.lines)
+	}
+
+	canvas.SetBackgroundColor("#ffffff")
+	canvas.Blank(300, 200)
+
+	canvas.SetFillColor("#000000")
+
+	box := &TextBox{
+		X:                 10,
+		Y:                 10,
+		Width:             280,
+		Height:            60,
+		Alignment:         CenterAlign,
+		VerticalAlignment: MiddleAlign,
+		Overflow:          ShrinkOverflow,
+	}
+
+	if err := canvas.DrawText(text+". "+text+".", box); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	if canvas.FontSize() != 24 {
+		t.Errorf("Got %f, expecting %f.", canvas.FontSize(), 24.0)
+	}
+
+	box.Y = 110
+	box.Overflow = ClipOverflow
+
+	if err := canvas.DrawText(text+"\n"+text, box); err != nil {
+		t.Errorf("Error: %s\n", err)
+	}
+
+	canvas.Write("_examples/output/example-text-box.png")
 }
 
 func TestBlur(t *testing.T) {
@@ -0,0 +1,218 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Channel of a mask canvas that decides which pixels are affected.
+type MaskSource uint
+
+const (
+	// Opaque pixels of the mask are affected, transparent ones are not.
+	AlphaMask MaskSource = iota
+	// Bright pixels of the mask are affected, dark ones are not.
+	LuminanceMask
+)
+
+// Region built from drawing primitives, in canvas coordinates. Set it with
+// Canvas.SetClipPath() to restrict drawing and compositing.
+type ClipPath struct {
+	shapes []func(c *Canvas)
+}
+
+// Returns an empty clip path.
+func NewClipPath() *ClipPath {
+	return &ClipPath{}
+}
+
+// Adds a rectangle to the clip path.
+func (self *ClipPath) Rectangle(x float64, y float64, width float64, height float64) {
+	self.shapes = append(self.shapes, func(c *Canvas) {
+		C.DrawRectangle(c.drawing, C.double(x), C.double(y), C.double(x+width), C.double(y+height))
+	})
+}
+
+// Adds a rectangle with rounded corners to the clip path.
+func (self *ClipPath) RoundRectangle(x float64, y float64, width float64, height float64, rx float64, ry float64) {
+	self.shapes = append(self.shapes, func(c *Canvas) {
+		C.DrawRoundRectangle(c.drawing, C.double(x), C.double(y), C.double(x+width), C.double(y+height), C.double(rx), C.double(ry))
+	})
+}
+
+// Adds a circle to the clip path.
+func (self *ClipPath) Circle(cx float64, cy float64, radius float64) {
+	self.shapes = append(self.shapes, func(c *Canvas) {
+		C.DrawCircle(c.drawing, C.double(cx), C.double(cy), C.double(cx+radius), C.double(cy))
+	})
+}
+
+// Adds an ellipse to the clip path.
+func (self *ClipPath) Ellipse(cx float64, cy float64, rx float64, ry float64) {
+	self.shapes = append(self.shapes, func(c *Canvas) {
+		C.DrawEllipse(c.drawing, C.double(cx), C.double(cy), C.double(rx), C.double(ry), 0, 360)
+	})
+}
+
+// Adds a polygon to the clip path.
+func (self *ClipPath) Polygon(points []Point) {
+	self.shapes = append(self.shapes, func(c *Canvas) {
+		c.Polygon(points)
+	})
+}
+
+// Adds an arbitrary shape to the clip path. fn draws it using the canvas
+// path API (PathStart(), MoveTo(), LineTo()...).
+func (self *ClipPath) Path(fn func(c *Canvas)) {
+	self.shapes = append(self.shapes, fn)
+}
+
+// Private: sets mask as the clip mask of the current image. Pixels where the
+// mask is black can be modified, pixels where it's white are protected.
+func (self *Canvas) setClipMask(mask *Canvas) error {
+	if C.MagickSetImageClipMask(self.wand, mask.wand) == C.MagickFalse {
+		return self.magickError("set clip mask")
+	}
+
+	return nil
+}
+
+// Private: defines path on the drawing surface and makes it the current clip
+// path of the drawing surface only.
+func (self *Canvas) setDrawingClipPath(path *ClipPath) error {
+	self.clipPaths++
+	id := fmt.Sprintf("clip%d", self.clipPaths)
+
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+
+	C.DrawPushClipPath(self.drawing, cid)
+	for _, shape := range path.shapes {
+		shape(self)
+	}
+	C.DrawPopClipPath(self.drawing)
+
+	if C.DrawSetClipPath(self.drawing, cid) == C.MagickFalse {
+		return self.drawingError("set clip path")
+	}
+
+	return nil
+}
+
+// Restricts subsequent drawing, AppendCanvas() and Composite() on the
+// current image to the area of path. Drawing queued so far is rendered
+// first, so it isn't clipped. Use ClearClip() to stop clipping.
+func (self *Canvas) SetClipPath(path *ClipPath) error {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return errors.New("Could not set clip path: canvas is empty.")
+	}
+
+	if err := self.Update(); err != nil {
+		return err
+	}
+
+	if err := self.setDrawingClipPath(path); err != nil {
+		return err
+	}
+
+	// Image, the path is rendered as a black shape on a white mask.
+	mask := New()
+	defer mask.Destroy()
+
+	mask.SetBackgroundColor("#ffffff")
+
+	if err := mask.Blank(width, height); err != nil {
+		return err
+	}
+
+	mask.SetFillColor("#000000")
+	mask.SetStrokeColor("none")
+
+	for _, shape := range path.shapes {
+		shape(mask)
+	}
+
+	if err := mask.Update(); err != nil {
+		return err
+	}
+
+	return self.setClipMask(mask)
+}
+
+// Restricts drawing, AppendCanvas() and Composite() on the current image to
+// the pixels selected by mask, which must have the same size as the canvas.
+// Drawing queued so far is rendered first, so it isn't clipped.
+func (self *Canvas) SetClipMask(mask *Canvas, source MaskSource) error {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return errors.New("Could not set clip mask: canvas is empty.")
+	}
+
+	if mask.Width() != width || mask.Height() != height {
+		return fmt.Errorf("Could not set clip mask: mask is %dx%d, expecting %dx%d.", mask.Width(), mask.Height(), width, height)
+	}
+
+	if err := self.Update(); err != nil {
+		return err
+	}
+
+	channel := "A"
+	if source == LuminanceMask {
+		channel = "I"
+	}
+
+	pix := make([]uint8, width*height)
+
+	if err := mask.exportPixels(0, 0, width, height, channel, C.CharPixel, unsafe.Pointer(&pix[0])); err != nil {
+		return err
+	}
+
+	// ImageMagick protects bright pixels.
+	for i := range pix {
+		pix[i] = 0xff - pix[i]
+	}
+
+	clip := New()
+	defer clip.Destroy()
+
+	clip.SetBackgroundColor("#000000")
+
+	if err := clip.Blank(width, height); err != nil {
+		return err
+	}
+
+	if err := clip.importPixels(0, 0, width, height, "I", C.CharPixel, unsafe.Pointer(&pix[0])); err != nil {
+		return err
+	}
+
+	return self.setClipMask(clip)
+}
+
+// Stops clipping the current image after SetClipPath() or SetClipMask().
+// Drawing queued so far is rendered first, so it's still clipped.
+func (self *Canvas) ClearClip() {
+	if self.Frames() == 0 {
+		return
+	}
+
+	self.Update()
+
+	C.SetImageClipMask(C.GetImageFromMagickWand(self.wand), nil)
+
+	// The drawing surface can't unset its clip path, later drawing is
+	// clipped to the whole canvas instead.
+	if self.clipPaths > 0 {
+		path := NewClipPath()
+		path.Rectangle(0, 0, float64(self.Width()), float64(self.Height()))
+		self.setDrawingClipPath(path)
+	}
+}
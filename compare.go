@@ -0,0 +1,171 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// Half the side of the window used to compute SSIM.
+const ssimRadius = 3
+
+// Private: returns the intensity of every pixel of the current image,
+// ranging from 0 to 1.
+func (self *Canvas) intensities() ([]float64, error) {
+	width, height := self.Width(), self.Height()
+
+	pix := make([]float64, width*height)
+
+	if err := self.exportPixels(0, 0, width, height, "I", C.DoublePixel, unsafe.Pointer(&pix[0])); err != nil {
+		return nil, err
+	}
+
+	return pix, nil
+}
+
+// Private: returns the summed-area table of values, a width x height grid,
+// with an extra leading row and column of zeros.
+func integral(values []float64, width int, height int) []float64 {
+	table := make([]float64, (width+1)*(height+1))
+
+	for y := 0; y < height; y++ {
+		var row float64
+		for x := 0; x < width; x++ {
+			row += values[y*width+x]
+			table[(y+1)*(width+1)+x+1] = table[y*(width+1)+x+1] + row
+		}
+	}
+
+	return table
+}
+
+// Private: returns the sum of the (x0, y0)-(x1, y1) rectangle of an
+// integral table, bounds excluded on the upper side.
+func integralSum(table []float64, width int, x0, y0, x1, y1 int) float64 {
+	stride := width + 1
+	return table[y1*stride+x1] - table[y0*stride+x1] - table[y1*stride+x0] + table[y0*stride+x0]
+}
+
+// Private: returns the mean structural similarity of the intensities of two
+// images and a map of their local dissimilarity, from 0 (identical) to 1.
+func ssim(a []float64, b []float64, width int, height int) (float64, []float64) {
+	const c1 = 0.01 * 0.01
+	const c2 = 0.03 * 0.03
+
+	n := len(a)
+
+	aa := make([]float64, n)
+	bb := make([]float64, n)
+	ab := make([]float64, n)
+
+	for i := range a {
+		aa[i] = a[i] * a[i]
+		bb[i] = b[i] * b[i]
+		ab[i] = a[i] * b[i]
+	}
+
+	sa, sb := integral(a, width, height), integral(b, width, height)
+	saa, sbb, sab := integral(aa, width, height), integral(bb, width, height), integral(ab, width, height)
+
+	dissimilarity := make([]float64, n)
+
+	var total float64
+
+	for y := 0; y < height; y++ {
+		y0, y1 := max(0, y-ssimRadius), min(height, y+ssimRadius+1)
+
+		for x := 0; x < width; x++ {
+			x0, x1 := max(0, x-ssimRadius), min(width, x+ssimRadius+1)
+
+			count := float64((x1 - x0) * (y1 - y0))
+
+			meanA := integralSum(sa, width, x0, y0, x1, y1) / count
+			meanB := integralSum(sb, width, x0, y0, x1, y1) / count
+			varA := integralSum(saa, width, x0, y0, x1, y1)/count - meanA*meanA
+			varB := integralSum(sbb, width, x0, y0, x1, y1)/count - meanB*meanB
+			covariance := integralSum(sab, width, x0, y0, x1, y1)/count - meanA*meanB
+
+			s := ((2*meanA*meanB + c1) * (2*covariance + c2)) / ((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+
+			total += s
+			dissimilarity[y*width+x] = math.Max(0, math.Min(1, 1-s))
+		}
+	}
+
+	return total / float64(n), dissimilarity
+}
+
+// Private: compares the intensities of the current images of the canvas and
+// other with SSIM, see Compare().
+func (self *Canvas) compareSSIM(other *Canvas) (float64, *Canvas, error) {
+	a, err := self.intensities()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	b, err := other.intensities()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	width, height := self.Width(), self.Height()
+
+	similarity, dissimilarity := ssim(a, b, int(width), int(height))
+
+	diff := New()
+
+	diff.SetBackgroundColor("#000000")
+
+	if err := diff.Blank(width, height); err != nil {
+		diff.Destroy()
+		return 0, nil, err
+	}
+
+	if err := diff.importPixels(0, 0, width, height, "I", C.DoublePixel, unsafe.Pointer(&dissimilarity[0])); err != nil {
+		diff.Destroy()
+		return 0, nil, err
+	}
+
+	return similarity, diff, nil
+}
+
+// Compares the current image of the canvas with the current image of other,
+// which must have the same size, and returns the distortion measured by
+// metric and a canvas that shows the differences. The caller is responsible
+// for destroying the returned canvas.
+//
+// Error metrics are 0 for identical images. PEAK_SIGNAL_TO_NOISE_RATIO_METRIC
+// and NORMALIZED_CROSS_CORRELATION_METRIC grow with similarity, and so does
+// SSIM_METRIC, which is 1 for identical images and is computed on
+// intensities; its difference canvas is brighter where images differ more.
+func (self *Canvas) Compare(other *Canvas, metric Metric) (float64, *Canvas, error) {
+	if self.Frames() == 0 || other.Frames() == 0 {
+		return 0, nil, errors.New("Could not compare images: canvas is empty.")
+	}
+
+	if self.Width() != other.Width() || self.Height() != other.Height() {
+		return 0, nil, fmt.Errorf("Could not compare images: %dx%d and %dx%d differ in size.", self.Width(), self.Height(), other.Width(), other.Height())
+	}
+
+	if metric == SSIM_METRIC {
+		return self.compareSSIM(other)
+	}
+
+	var distortion C.double
+
+	wand := C.MagickCompareImages(self.wand, other.wand, C.MetricType(metric), &distortion)
+
+	if wand == nil {
+		return 0, nil, self.magickError("compare images")
+	}
+
+	return float64(distortion), newCanvasFromWand(wand), nil
+}
@@ -0,0 +1,132 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+)
+
+type compositeOptions struct {
+	gravity uint
+	opacity float64
+}
+
+// Optional setting for Composite().
+type CompositeOption func(*compositeOptions)
+
+// Makes the x and y offsets of Composite() relative to the given side or
+// corner of the canvas, see the *_GRAVITY constants. Offsets point inwards,
+// e.g. with SOUTH_EAST_GRAVITY a positive x moves the source to the left.
+func WithGravity(gravity uint) CompositeOption {
+	return func(options *compositeOptions) {
+		options.gravity = gravity
+	}
+}
+
+// Multiplies the alpha of the source by opacity, from 0 (transparent) to 1
+// (unchanged).
+func WithOpacity(opacity float64) CompositeOption {
+	return func(options *compositeOptions) {
+		options.opacity = math.Max(0, math.Min(1, opacity))
+	}
+}
+
+// Private: returns the top-left position of a width x height box placed at
+// (x, y) relative to gravity within a canvasWidth x canvasHeight area.
+func gravityOffset(gravity uint, canvasWidth uint, canvasHeight uint, width uint, height uint, x int, y int) (int, int) {
+	dx := int(canvasWidth) - int(width)
+	dy := int(canvasHeight) - int(height)
+
+	switch gravity {
+	case NORTH_GRAVITY, CENTER_GRAVITY, SOUTH_GRAVITY:
+		x = dx/2 + x
+	case NORTH_EAST_GRAVITY, EAST_GRAVITY, SOUTH_EAST_GRAVITY:
+		x = dx - x
+	}
+
+	switch gravity {
+	case WEST_GRAVITY, CENTER_GRAVITY, EAST_GRAVITY:
+		y = dy/2 + y
+	case SOUTH_WEST_GRAVITY, SOUTH_GRAVITY, SOUTH_EAST_GRAVITY:
+		y = dy - y
+	}
+
+	return x, y
+}
+
+// Private: returns a copy of the current image of the canvas with its alpha
+// multiplied by opacity.
+func (self *Canvas) withOpacity(opacity float64) (*Canvas, error) {
+	wand := C.MagickGetImage(self.wand)
+
+	if wand == nil {
+		return nil, self.magickError("copy image")
+	}
+
+	clone := newCanvasFromWand(wand)
+
+	width, height := clone.Width(), clone.Height()
+
+	alpha := make([]uint16, width*height)
+
+	if err := clone.exportPixels(0, 0, width, height, "A", C.ShortPixel, unsafe.Pointer(&alpha[0])); err != nil {
+		clone.Destroy()
+		return nil, err
+	}
+
+	for i := range alpha {
+		alpha[i] = uint16(float64(alpha[i]) * opacity)
+	}
+
+	if err := clone.importPixels(0, 0, width, height, "A", C.ShortPixel, unsafe.Pointer(&alpha[0])); err != nil {
+		clone.Destroy()
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+// Combines the current image of source with the current image of the canvas
+// using op, placing it at (x, y). See the *_COMPOSITE constants and the
+// WithGravity() and WithOpacity() options.
+func (self *Canvas) Composite(source *Canvas, op CompositeOp, x int, y int, opts ...CompositeOption) error {
+	options := compositeOptions{
+		gravity: NORTH_WEST_GRAVITY,
+		opacity: 1,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if source.Frames() == 0 {
+		return errors.New("Could not composite image: source canvas is empty.")
+	}
+
+	x, y = gravityOffset(options.gravity, self.Width(), self.Height(), source.Width(), source.Height(), x, y)
+
+	if options.opacity < 1 {
+		translucent, err := source.withOpacity(options.opacity)
+
+		if err != nil {
+			return err
+		}
+
+		defer translucent.Destroy()
+
+		source = translucent
+	}
+
+	success := C.MagickCompositeImage(self.wand, source.wand, C.CompositeOperator(op), C.ssize_t(x), C.ssize_t(y))
+
+	if success == C.MagickFalse {
+		return self.magickError("composite image")
+	}
+
+	return nil
+}
@@ -97,4 +97,149 @@ const (
 	SOUTH_WEST_GRAVITY = uint(C.SouthWestGravity)
 	SOUTH_GRAVITY      = uint(C.SouthGravity)
 	SOUTH_EAST_GRAVITY = uint(C.SouthEastGravity)
+
+	UNDEFINED_DISPOSE  = uint(C.UndefinedDispose)
+	NONE_DISPOSE       = uint(C.NoneDispose)
+	BACKGROUND_DISPOSE = uint(C.BackgroundDispose)
+	PREVIOUS_DISPOSE   = uint(C.PreviousDispose)
+
+	AREA_RESOURCE   = uint(C.AreaResource)
+	DISK_RESOURCE   = uint(C.DiskResource)
+	FILE_RESOURCE   = uint(C.FileResource)
+	MAP_RESOURCE    = uint(C.MapResource)
+	MEMORY_RESOURCE = uint(C.MemoryResource)
+	THREAD_RESOURCE = uint(C.ThreadResource)
+	TIME_RESOURCE   = uint(C.TimeResource)
+)
+
+// Operator used to combine a source image with the canvas, see Composite().
+type CompositeOp uint
+
+const (
+	UNDEFINED_COMPOSITE         CompositeOp = CompositeOp(C.UndefinedCompositeOp)
+	NO_COMPOSITE                CompositeOp = CompositeOp(C.NoCompositeOp)
+	MODULUS_ADD_COMPOSITE       CompositeOp = CompositeOp(C.ModulusAddCompositeOp)
+	ATOP_COMPOSITE              CompositeOp = CompositeOp(C.AtopCompositeOp)
+	BLEND_COMPOSITE             CompositeOp = CompositeOp(C.BlendCompositeOp)
+	BUMPMAP_COMPOSITE           CompositeOp = CompositeOp(C.BumpmapCompositeOp)
+	CHANGE_MASK_COMPOSITE       CompositeOp = CompositeOp(C.ChangeMaskCompositeOp)
+	CLEAR_COMPOSITE             CompositeOp = CompositeOp(C.ClearCompositeOp)
+	COLOR_BURN_COMPOSITE        CompositeOp = CompositeOp(C.ColorBurnCompositeOp)
+	COLOR_DODGE_COMPOSITE       CompositeOp = CompositeOp(C.ColorDodgeCompositeOp)
+	COLORIZE_COMPOSITE          CompositeOp = CompositeOp(C.ColorizeCompositeOp)
+	COPY_BLACK_COMPOSITE        CompositeOp = CompositeOp(C.CopyBlackCompositeOp)
+	COPY_BLUE_COMPOSITE         CompositeOp = CompositeOp(C.CopyBlueCompositeOp)
+	COPY_COMPOSITE              CompositeOp = CompositeOp(C.CopyCompositeOp)
+	COPY_CYAN_COMPOSITE         CompositeOp = CompositeOp(C.CopyCyanCompositeOp)
+	COPY_GREEN_COMPOSITE        CompositeOp = CompositeOp(C.CopyGreenCompositeOp)
+	COPY_MAGENTA_COMPOSITE      CompositeOp = CompositeOp(C.CopyMagentaCompositeOp)
+	COPY_OPACITY_COMPOSITE      CompositeOp = CompositeOp(C.CopyOpacityCompositeOp)
+	COPY_RED_COMPOSITE          CompositeOp = CompositeOp(C.CopyRedCompositeOp)
+	COPY_YELLOW_COMPOSITE       CompositeOp = CompositeOp(C.CopyYellowCompositeOp)
+	DARKEN_COMPOSITE            CompositeOp = CompositeOp(C.DarkenCompositeOp)
+	DST_ATOP_COMPOSITE          CompositeOp = CompositeOp(C.DstAtopCompositeOp)
+	DST_COMPOSITE               CompositeOp = CompositeOp(C.DstCompositeOp)
+	DST_IN_COMPOSITE            CompositeOp = CompositeOp(C.DstInCompositeOp)
+	DST_OUT_COMPOSITE           CompositeOp = CompositeOp(C.DstOutCompositeOp)
+	DST_OVER_COMPOSITE          CompositeOp = CompositeOp(C.DstOverCompositeOp)
+	DIFFERENCE_COMPOSITE        CompositeOp = CompositeOp(C.DifferenceCompositeOp)
+	DISPLACE_COMPOSITE          CompositeOp = CompositeOp(C.DisplaceCompositeOp)
+	DISSOLVE_COMPOSITE          CompositeOp = CompositeOp(C.DissolveCompositeOp)
+	EXCLUSION_COMPOSITE         CompositeOp = CompositeOp(C.ExclusionCompositeOp)
+	HARD_LIGHT_COMPOSITE        CompositeOp = CompositeOp(C.HardLightCompositeOp)
+	HUE_COMPOSITE               CompositeOp = CompositeOp(C.HueCompositeOp)
+	IN_COMPOSITE                CompositeOp = CompositeOp(C.InCompositeOp)
+	LIGHTEN_COMPOSITE           CompositeOp = CompositeOp(C.LightenCompositeOp)
+	LINEAR_LIGHT_COMPOSITE      CompositeOp = CompositeOp(C.LinearLightCompositeOp)
+	LUMINIZE_COMPOSITE          CompositeOp = CompositeOp(C.LuminizeCompositeOp)
+	MINUS_DST_COMPOSITE         CompositeOp = CompositeOp(C.MinusDstCompositeOp)
+	MODULATE_COMPOSITE          CompositeOp = CompositeOp(C.ModulateCompositeOp)
+	MULTIPLY_COMPOSITE          CompositeOp = CompositeOp(C.MultiplyCompositeOp)
+	OUT_COMPOSITE               CompositeOp = CompositeOp(C.OutCompositeOp)
+	OVER_COMPOSITE              CompositeOp = CompositeOp(C.OverCompositeOp)
+	OVERLAY_COMPOSITE           CompositeOp = CompositeOp(C.OverlayCompositeOp)
+	PLUS_COMPOSITE              CompositeOp = CompositeOp(C.PlusCompositeOp)
+	REPLACE_COMPOSITE           CompositeOp = CompositeOp(C.ReplaceCompositeOp)
+	SATURATE_COMPOSITE          CompositeOp = CompositeOp(C.SaturateCompositeOp)
+	SCREEN_COMPOSITE            CompositeOp = CompositeOp(C.ScreenCompositeOp)
+	SOFT_LIGHT_COMPOSITE        CompositeOp = CompositeOp(C.SoftLightCompositeOp)
+	SRC_ATOP_COMPOSITE          CompositeOp = CompositeOp(C.SrcAtopCompositeOp)
+	SRC_COMPOSITE               CompositeOp = CompositeOp(C.SrcCompositeOp)
+	SRC_IN_COMPOSITE            CompositeOp = CompositeOp(C.SrcInCompositeOp)
+	SRC_OUT_COMPOSITE           CompositeOp = CompositeOp(C.SrcOutCompositeOp)
+	SRC_OVER_COMPOSITE          CompositeOp = CompositeOp(C.SrcOverCompositeOp)
+	MODULUS_SUBTRACT_COMPOSITE  CompositeOp = CompositeOp(C.ModulusSubtractCompositeOp)
+	THRESHOLD_COMPOSITE         CompositeOp = CompositeOp(C.ThresholdCompositeOp)
+	XOR_COMPOSITE               CompositeOp = CompositeOp(C.XorCompositeOp)
+	DIVIDE_DST_COMPOSITE        CompositeOp = CompositeOp(C.DivideDstCompositeOp)
+	DISTORT_COMPOSITE           CompositeOp = CompositeOp(C.DistortCompositeOp)
+	BLUR_COMPOSITE              CompositeOp = CompositeOp(C.BlurCompositeOp)
+	PEGTOP_LIGHT_COMPOSITE      CompositeOp = CompositeOp(C.PegtopLightCompositeOp)
+	VIVID_LIGHT_COMPOSITE       CompositeOp = CompositeOp(C.VividLightCompositeOp)
+	PIN_LIGHT_COMPOSITE         CompositeOp = CompositeOp(C.PinLightCompositeOp)
+	LINEAR_DODGE_COMPOSITE      CompositeOp = CompositeOp(C.LinearDodgeCompositeOp)
+	LINEAR_BURN_COMPOSITE       CompositeOp = CompositeOp(C.LinearBurnCompositeOp)
+	MATHEMATICS_COMPOSITE       CompositeOp = CompositeOp(C.MathematicsCompositeOp)
+	DIVIDE_SRC_COMPOSITE        CompositeOp = CompositeOp(C.DivideSrcCompositeOp)
+	MINUS_SRC_COMPOSITE         CompositeOp = CompositeOp(C.MinusSrcCompositeOp)
+	DARKEN_INTENSITY_COMPOSITE  CompositeOp = CompositeOp(C.DarkenIntensityCompositeOp)
+	LIGHTEN_INTENSITY_COMPOSITE CompositeOp = CompositeOp(C.LightenIntensityCompositeOp)
+)
+
+// Measure of the difference between two images, see Compare().
+type Metric uint
+
+const (
+	ABSOLUTE_ERROR_METRIC               Metric = Metric(C.AbsoluteErrorMetric)
+	MEAN_ABSOLUTE_ERROR_METRIC          Metric = Metric(C.MeanAbsoluteErrorMetric)
+	MEAN_ERROR_PER_PIXEL_METRIC         Metric = Metric(C.MeanErrorPerPixelMetric)
+	MEAN_SQUARED_ERROR_METRIC           Metric = Metric(C.MeanSquaredErrorMetric)
+	PEAK_ABSOLUTE_ERROR_METRIC          Metric = Metric(C.PeakAbsoluteErrorMetric)
+	PEAK_SIGNAL_TO_NOISE_RATIO_METRIC   Metric = Metric(C.PeakSignalToNoiseRatioMetric)
+	ROOT_MEAN_SQUARED_ERROR_METRIC      Metric = Metric(C.RootMeanSquaredErrorMetric)
+	NORMALIZED_CROSS_CORRELATION_METRIC Metric = Metric(C.NormalizedCrossCorrelationErrorMetric)
+	FUZZ_ERROR_METRIC                   Metric = Metric(C.FuzzErrorMetric)
+	// Structural similarity, computed by this package.
+	SSIM_METRIC Metric = 1 << 16
+)
+
+// Colorspace of an image, see Colorspace() and TransformColorspace().
+type Colorspace uint
+
+const (
+	UNDEFINED_COLORSPACE    Colorspace = Colorspace(C.UndefinedColorspace)
+	RGB_COLORSPACE          Colorspace = Colorspace(C.RGBColorspace)
+	GRAY_COLORSPACE         Colorspace = Colorspace(C.GRAYColorspace)
+	TRANSPARENT_COLORSPACE  Colorspace = Colorspace(C.TransparentColorspace)
+	OHTA_COLORSPACE         Colorspace = Colorspace(C.OHTAColorspace)
+	LAB_COLORSPACE          Colorspace = Colorspace(C.LabColorspace)
+	XYZ_COLORSPACE          Colorspace = Colorspace(C.XYZColorspace)
+	YCBCR_COLORSPACE        Colorspace = Colorspace(C.YCbCrColorspace)
+	YCC_COLORSPACE          Colorspace = Colorspace(C.YCCColorspace)
+	YIQ_COLORSPACE          Colorspace = Colorspace(C.YIQColorspace)
+	YPBPR_COLORSPACE        Colorspace = Colorspace(C.YPbPrColorspace)
+	YUV_COLORSPACE          Colorspace = Colorspace(C.YUVColorspace)
+	CMYK_COLORSPACE         Colorspace = Colorspace(C.CMYKColorspace)
+	SRGB_COLORSPACE         Colorspace = Colorspace(C.sRGBColorspace)
+	HSB_COLORSPACE          Colorspace = Colorspace(C.HSBColorspace)
+	HSL_COLORSPACE          Colorspace = Colorspace(C.HSLColorspace)
+	HWB_COLORSPACE          Colorspace = Colorspace(C.HWBColorspace)
+	REC601_LUMA_COLORSPACE  Colorspace = Colorspace(C.Rec601LumaColorspace)
+	REC601_YCBCR_COLORSPACE Colorspace = Colorspace(C.Rec601YCbCrColorspace)
+	REC709_LUMA_COLORSPACE  Colorspace = Colorspace(C.Rec709LumaColorspace)
+	REC709_YCBCR_COLORSPACE Colorspace = Colorspace(C.Rec709YCbCrColorspace)
+	LOG_COLORSPACE          Colorspace = Colorspace(C.LogColorspace)
+	CMY_COLORSPACE          Colorspace = Colorspace(C.CMYColorspace)
+)
+
+// How colors out of the gamut of the destination profile are mapped, see
+// ConvertToProfile().
+type RenderingIntent uint
+
+const (
+	UNDEFINED_INTENT  RenderingIntent = RenderingIntent(C.UndefinedIntent)
+	SATURATION_INTENT RenderingIntent = RenderingIntent(C.SaturationIntent)
+	PERCEPTUAL_INTENT RenderingIntent = RenderingIntent(C.PerceptualIntent)
+	ABSOLUTE_INTENT   RenderingIntent = RenderingIntent(C.AbsoluteIntent)
+	RELATIVE_INTENT   RenderingIntent = RenderingIntent(C.RelativeIntent)
 )
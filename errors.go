@@ -0,0 +1,153 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// Severity of an exception reported by ImageMagick.
+type Severity uint
+
+const (
+	UndefinedSeverity Severity = iota
+	WarningSeverity
+	ErrorSeverity
+	FatalSeverity
+)
+
+// Returns the name of the severity.
+func (self Severity) String() string {
+	switch self {
+	case WarningSeverity:
+		return "warning"
+	case ErrorSeverity:
+		return "error"
+	case FatalSeverity:
+		return "fatal"
+	}
+	return "undefined"
+}
+
+// Error reported by the MagickWand API.
+type MagickError struct {
+	// Operation that failed, e.g. "resize".
+	Op string
+	// Whether the exception is a warning, an error or a fatal error.
+	Severity Severity
+	// ImageMagick's ExceptionType code.
+	Code uint
+	// Message reported by ImageMagick.
+	Message string
+}
+
+// Sentinel errors that can be matched against a *MagickError with errors.Is,
+// regardless of its severity.
+var (
+	ErrResourceLimit   = &MagickError{Code: uint(C.ResourceLimitError), Severity: ErrorSeverity, Message: "resource limit exceeded"}
+	ErrCorruptImage    = &MagickError{Code: uint(C.CorruptImageError), Severity: ErrorSeverity, Message: "corrupt image"}
+	ErrMissingDelegate = &MagickError{Code: uint(C.MissingDelegateError), Severity: ErrorSeverity, Message: "no delegate for this image format"}
+	ErrFileOpen        = &MagickError{Code: uint(C.FileOpenError), Severity: ErrorSeverity, Message: "unable to open file"}
+)
+
+// Private: returns the severity of an ExceptionType code. ImageMagick uses
+// 300-399 for warnings, 400-699 for errors and 700+ for fatal errors.
+func exceptionSeverity(code uint) Severity {
+	switch {
+	case code >= uint(C.FatalErrorException):
+		return FatalSeverity
+	case code >= uint(C.ErrorException):
+		return ErrorSeverity
+	case code >= uint(C.WarningException):
+		return WarningSeverity
+	}
+	return UndefinedSeverity
+}
+
+// Private: builds a *MagickError from an exception type and a message
+// allocated by MagickWand. The message is released.
+func newMagickError(op string, code C.ExceptionType, ptr *C.char) *MagickError {
+	message := C.GoString(ptr)
+
+	C.MagickRelinquishMemory(unsafe.Pointer(ptr))
+
+	return &MagickError{
+		Op:       op,
+		Severity: exceptionSeverity(uint(code)),
+		Code:     uint(code),
+		Message:  message,
+	}
+}
+
+func (self *MagickError) Error() string {
+	if self.Op == "" {
+		return self.Message
+	}
+	return "Could not " + self.Op + ": " + self.Message
+}
+
+// Reports whether target is a *MagickError of the same exception class
+// (e.g. corrupt image or missing delegate), warnings and fatal errors
+// included.
+func (self *MagickError) Is(target error) bool {
+	t, ok := target.(*MagickError)
+
+	if !ok {
+		return false
+	}
+
+	if self.Code < uint(C.WarningException) || t.Code < uint(C.WarningException) {
+		return self.Code == t.Code
+	}
+
+	return self.Code%100 == t.Code%100
+}
+
+// Returns true if err is a *MagickError with warning severity. Warnings
+// don't stop an operation, callers may log them instead of failing.
+func IsWarning(err error) bool {
+	var magickErr *MagickError
+
+	if errors.As(err, &magickErr) {
+		return magickErr.Severity == WarningSeverity
+	}
+
+	return false
+}
+
+// Private: returns the exception of the canvas' wand as a *MagickError for
+// op, clearing it.
+func (self *Canvas) magickError(op string) error {
+	var code C.ExceptionType
+
+	ptr := C.MagickGetException(self.wand, &code)
+	C.MagickClearException(self.wand)
+
+	return newMagickError(op, code, ptr)
+}
+
+// Private: returns the exception of the canvas' drawing surface as a
+// *MagickError for op, clearing it.
+func (self *Canvas) drawingError(op string) error {
+	var code C.ExceptionType
+
+	ptr := C.DrawGetException(self.drawing, &code)
+	C.DrawClearException(self.drawing)
+
+	return newMagickError(op, code, ptr)
+}
+
+// Private: returns the exception of the iterator as a *MagickError for op,
+// clearing it.
+func (self *PixelIterator) magickError(op string) error {
+	var code C.ExceptionType
+
+	ptr := C.PixelGetIteratorException(self.iterator, &code)
+	C.PixelClearIteratorException(self.iterator)
+
+	return newMagickError(op, code, ptr)
+}
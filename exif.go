@@ -0,0 +1,843 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EXIF tags handled by this package.
+const (
+	exifTagOrientation     = 0x0112
+	exifTagArtist          = 0x013b
+	exifTagThumbnailOffset = 0x0201
+	exifTagThumbnailLength = 0x0202
+	exifTagCopyright       = 0x8298
+	exifTagExifIFD         = 0x8769
+	exifTagGPSIFD          = 0x8825
+	exifTagMakerNote       = 0x927c
+	exifTagInteropIFD      = 0xa005
+)
+
+// EXIF value types.
+const (
+	exifByte      = 1
+	exifASCII     = 2
+	exifShort     = 3
+	exifLong      = 4
+	exifRational  = 5
+	exifSByte     = 6
+	exifUndefined = 7
+	exifSShort    = 8
+	exifSLong     = 9
+	exifSRational = 10
+	exifFloat     = 11
+	exifDouble    = 12
+	exifIFDType   = 13
+)
+
+// Size in bytes of each EXIF value type.
+var exifTypeSizes = map[uint16]uint32{
+	exifByte:      1,
+	exifASCII:     1,
+	exifShort:     2,
+	exifLong:      4,
+	exifRational:  8,
+	exifSByte:     1,
+	exifUndefined: 1,
+	exifSShort:    2,
+	exifSLong:     4,
+	exifSRational: 8,
+	exifFloat:     4,
+	exifDouble:    8,
+	exifIFDType:   4,
+}
+
+// Names of the tags of IFD0, IFD1 and the Exif IFD, as used by ImageMagick
+// for "exif:*" properties.
+var exifTagNames = map[uint16]string{
+	0x00fe: "NewSubfileType",
+	0x00ff: "SubfileType",
+	0x0100: "ImageWidth",
+	0x0101: "ImageLength",
+	0x0102: "BitsPerSample",
+	0x0103: "Compression",
+	0x0106: "PhotometricInterpretation",
+	0x010a: "FillOrder",
+	0x010d: "DocumentName",
+	0x010e: "ImageDescription",
+	0x010f: "Make",
+	0x0110: "Model",
+	0x0111: "StripOffsets",
+	0x0112: "Orientation",
+	0x0115: "SamplesPerPixel",
+	0x0116: "RowsPerStrip",
+	0x0117: "StripByteCounts",
+	0x011a: "XResolution",
+	0x011b: "YResolution",
+	0x011c: "PlanarConfiguration",
+	0x011d: "PageName",
+	0x011e: "XPosition",
+	0x011f: "YPosition",
+	0x0128: "ResolutionUnit",
+	0x012d: "TransferFunction",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013b: "Artist",
+	0x013c: "HostComputer",
+	0x013d: "Predictor",
+	0x013e: "WhitePoint",
+	0x013f: "PrimaryChromaticities",
+	0x0140: "ColorMap",
+	0x0141: "HalfToneHints",
+	0x0142: "TileWidth",
+	0x0143: "TileLength",
+	0x0144: "TileOffsets",
+	0x0145: "TileByteCounts",
+	0x014a: "SubIFD",
+	0x014c: "InkSet",
+	0x0151: "TargetPrinter",
+	0x0156: "TransferRange",
+	0x0201: "JPEGInterchangeFormat",
+	0x0202: "JPEGInterchangeFormatLength",
+	0x0211: "YCbCrCoefficients",
+	0x0212: "YCbCrSubSampling",
+	0x0213: "YCbCrPositioning",
+	0x0214: "ReferenceBlackWhite",
+	0x4746: "Rating",
+	0x4749: "RatingPercent",
+	0x8298: "Copyright",
+	0x829a: "ExposureTime",
+	0x829d: "FNumber",
+	0x83bb: "IPTC/NAA",
+	0x8649: "PhotoshopSettings",
+	0x8769: "ExifOffset",
+	0x8773: "InterColorProfile",
+	0x8822: "ExposureProgram",
+	0x8824: "SpectralSensitivity",
+	0x8825: "GPSInfo",
+	0x8827: "ISOSpeedRatings",
+	0x8828: "OECF",
+	0x8829: "Interlace",
+	0x882a: "TimeZoneOffset",
+	0x882b: "SelfTimerMode",
+	0x8830: "SensitivityType",
+	0x8831: "StandardOutputSensitivity",
+	0x8832: "RecommendedExposureIndex",
+	0x8833: "ISOSpeed",
+	0x8834: "ISOSpeedLatitudeyyy",
+	0x8835: "ISOSpeedLatitudezzz",
+	0x9000: "ExifVersion",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x9010: "OffsetTime",
+	0x9011: "OffsetTimeOriginal",
+	0x9012: "OffsetTimeDigitized",
+	0x9101: "ComponentsConfiguration",
+	0x9102: "CompressedBitsPerPixel",
+	0x9201: "ShutterSpeedValue",
+	0x9202: "ApertureValue",
+	0x9203: "BrightnessValue",
+	0x9204: "ExposureBiasValue",
+	0x9205: "MaxApertureValue",
+	0x9206: "SubjectDistance",
+	0x9207: "MeteringMode",
+	0x9208: "LightSource",
+	0x9209: "Flash",
+	0x920a: "FocalLength",
+	0x920b: "FlashEnergy",
+	0x920c: "SpatialFrequencyResponse",
+	0x920d: "Noise",
+	0x9211: "ImageNumber",
+	0x9212: "SecurityClassification",
+	0x9213: "ImageHistory",
+	0x9214: "SubjectArea",
+	0x9215: "ExposureIndex",
+	0x9216: "TIFF-EPStandardID",
+	0x927c: "MakerNote",
+	0x9286: "UserComment",
+	0x9290: "SubSecTime",
+	0x9291: "SubSecTimeOriginal",
+	0x9292: "SubSecTimeDigitized",
+	0x9c9b: "WinXP-Title",
+	0x9c9c: "WinXP-Comments",
+	0x9c9d: "WinXP-Author",
+	0x9c9e: "WinXP-Keywords",
+	0x9c9f: "WinXP-Subject",
+	0xa000: "FlashPixVersion",
+	0xa001: "ColorSpace",
+	0xa002: "ExifImageWidth",
+	0xa003: "ExifImageLength",
+	0xa004: "RelatedSoundFile",
+	0xa005: "InteroperabilityOffset",
+	0xa20b: "FlashEnergy",
+	0xa20c: "SpatialFrequencyResponse",
+	0xa20e: "FocalPlaneXResolution",
+	0xa20f: "FocalPlaneYResolution",
+	0xa210: "FocalPlaneResolutionUnit",
+	0xa214: "SubjectLocation",
+	0xa215: "ExposureIndex",
+	0xa217: "SensingMethod",
+	0xa300: "FileSource",
+	0xa301: "SceneType",
+	0xa302: "CFAPattern",
+	0xa401: "CustomRendered",
+	0xa402: "ExposureMode",
+	0xa403: "WhiteBalance",
+	0xa404: "DigitalZoomRatio",
+	0xa405: "FocalLengthIn35mmFilm",
+	0xa406: "SceneCaptureType",
+	0xa407: "GainControl",
+	0xa408: "Contrast",
+	0xa409: "Saturation",
+	0xa40a: "Sharpness",
+	0xa40b: "DeviceSettingDescription",
+	0xa40c: "SubjectDistanceRange",
+	0xa420: "ImageUniqueID",
+	0xa430: "CameraOwnerName",
+	0xa431: "BodySerialNumber",
+	0xa432: "LensSpecification",
+	0xa433: "LensMake",
+	0xa434: "LensModel",
+	0xa435: "LensSerialNumber",
+	0xa500: "Gamma",
+	0xc4a5: "PrintImageMatching",
+}
+
+// Names of the tags of the GPS IFD.
+var gpsTagNames = map[uint16]string{
+	0x00: "GPSVersionID",
+	0x01: "GPSLatitudeRef",
+	0x02: "GPSLatitude",
+	0x03: "GPSLongitudeRef",
+	0x04: "GPSLongitude",
+	0x05: "GPSAltitudeRef",
+	0x06: "GPSAltitude",
+	0x07: "GPSTimeStamp",
+	0x08: "GPSSatellites",
+	0x09: "GPSStatus",
+	0x0a: "GPSMeasureMode",
+	0x0b: "GPSDop",
+	0x0c: "GPSSpeedRef",
+	0x0d: "GPSSpeed",
+	0x0e: "GPSTrackRef",
+	0x0f: "GPSTrack",
+	0x10: "GPSImgDirectionRef",
+	0x11: "GPSImgDirection",
+	0x12: "GPSMapDatum",
+	0x13: "GPSDestLatitudeRef",
+	0x14: "GPSDestLatitude",
+	0x15: "GPSDestLongitudeRef",
+	0x16: "GPSDestLongitude",
+	0x17: "GPSDestBearingRef",
+	0x18: "GPSDestBearing",
+	0x19: "GPSDestDistanceRef",
+	0x1a: "GPSDestDistance",
+	0x1b: "GPSProcessingMethod",
+	0x1c: "GPSAreaInformation",
+	0x1d: "GPSDateStamp",
+	0x1e: "GPSDifferential",
+	0x1f: "GPSHPositioningError",
+}
+
+// Names of the tags of the Interoperability IFD.
+var interopTagNames = map[uint16]string{
+	0x0001: "InteroperabilityIndex",
+	0x0002: "InteroperabilityVersion",
+	0x1000: "RelatedImageFileFormat",
+	0x1001: "RelatedImageWidth",
+	0x1002: "RelatedImageLength",
+}
+
+// Header of EXIF profiles read from JPEG files.
+var exifHeader = []byte("Exif\x00\x00")
+
+// Sub-IFDs are not followed deeper than this, to survive loops.
+const exifMaxDepth = 4
+
+type exifEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// Raw value, in the byte order of the profile.
+	value []byte
+	// Offset of the value in the TIFF data, for values that don't fit in the
+	// entry.
+	offset uint32
+	// Pointed IFD, for sub-IFD tags.
+	sub *exifIFD
+}
+
+type exifIFD struct {
+	entries []exifEntry
+}
+
+// EXIF profile: IFD0 and its sub-IFDs, plus IFD1 when it holds a JPEG
+// thumbnail. Other IFDs are dropped.
+type exifData struct {
+	order  binary.ByteOrder
+	header bool
+	ifd0   *exifIFD
+	ifd1   *exifIFD
+	// JPEG data pointed by IFD1.
+	thumbnail []byte
+}
+
+// Private: returns true if entries of tag point to a sub-IFD.
+func isSubIFDTag(tag uint16) bool {
+	return tag == exifTagExifIFD || tag == exifTagGPSIFD || tag == exifTagInteropIFD
+}
+
+// Private: parses an EXIF profile.
+func parseExif(data []byte) (*exifData, error) {
+	exif := &exifData{}
+
+	if bytes.HasPrefix(data, exifHeader) {
+		exif.header = true
+		data = data[len(exifHeader):]
+	}
+
+	if len(data) < 8 {
+		return nil, errors.New("Could not parse EXIF: profile is too short.")
+	}
+
+	switch string(data[:2]) {
+	case "II":
+		exif.order = binary.LittleEndian
+	case "MM":
+		exif.order = binary.BigEndian
+	default:
+		return nil, errors.New("Could not parse EXIF: unknown byte order.")
+	}
+
+	if exif.order.Uint16(data[2:]) != 42 {
+		return nil, errors.New("Could not parse EXIF: bad TIFF header.")
+	}
+
+	ifd0, next, err := exif.parseIFD(data, exif.order.Uint32(data[4:]), 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	exif.ifd0 = ifd0
+
+	if next != 0 {
+		// A broken thumbnail IFD is dropped.
+		if ifd1, _, err := exif.parseIFD(data, next, 1); err == nil {
+			exif.parseThumbnail(data, ifd1)
+		}
+	}
+
+	return exif, nil
+}
+
+// Private: keeps ifd1 and the JPEG thumbnail it points to, if any.
+func (self *exifData) parseThumbnail(tiff []byte, ifd1 *exifIFD) {
+	offset, length := ifd1.entry(exifTagThumbnailOffset), ifd1.entry(exifTagThumbnailLength)
+
+	if offset == nil || length == nil || offset.typ != exifLong || length.typ != exifLong || len(offset.value) != 4 || len(length.value) != 4 {
+		return
+	}
+
+	start := uint64(self.order.Uint32(offset.value))
+	end := start + uint64(self.order.Uint32(length.value))
+
+	if end > uint64(len(tiff)) {
+		return
+	}
+
+	self.ifd1 = ifd1
+	self.thumbnail = append([]byte(nil), tiff[start:end]...)
+}
+
+// Private: parses the IFD at offset of tiff, returns it and the offset of
+// the next IFD.
+func (self *exifData) parseIFD(tiff []byte, offset uint32, depth int) (*exifIFD, uint32, error) {
+	if depth > exifMaxDepth {
+		return nil, 0, errors.New("Could not parse EXIF: too many nested IFDs.")
+	}
+
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return nil, 0, errors.New("Could not parse EXIF: IFD out of bounds.")
+	}
+
+	n := int(self.order.Uint16(tiff[offset:]))
+
+	if uint64(offset)+2+12*uint64(n) > uint64(len(tiff)) {
+		return nil, 0, errors.New("Could not parse EXIF: IFD out of bounds.")
+	}
+
+	ifd := &exifIFD{}
+
+	for i := 0; i < n; i++ {
+		raw := tiff[int(offset)+2+12*i:]
+
+		entry := exifEntry{
+			tag:   self.order.Uint16(raw),
+			typ:   self.order.Uint16(raw[2:]),
+			count: self.order.Uint32(raw[4:]),
+		}
+
+		typeSize, known := exifTypeSizes[entry.typ]
+
+		if !known {
+			continue
+		}
+
+		size := uint64(typeSize) * uint64(entry.count)
+
+		if size <= 4 {
+			entry.value = append([]byte(nil), raw[8:8+size]...)
+		} else {
+			entry.offset = self.order.Uint32(raw[8:])
+
+			if uint64(entry.offset)+size > uint64(len(tiff)) {
+				// Skip broken entries instead of giving up on the profile.
+				continue
+			}
+
+			entry.value = append([]byte(nil), tiff[entry.offset:uint64(entry.offset)+size]...)
+		}
+
+		if isSubIFDTag(entry.tag) {
+			if size != 4 {
+				continue
+			}
+
+			sub, _, err := self.parseIFD(tiff, self.order.Uint32(entry.value), depth+1)
+
+			if err != nil {
+				// Broken sub-IFDs are dropped with their pointer, which would
+				// point to unrelated data once rewritten.
+				continue
+			}
+
+			entry.sub = sub
+		}
+
+		ifd.entries = append(ifd.entries, entry)
+	}
+
+	var next uint32
+
+	if end := uint64(offset) + 2 + 12*uint64(n); end+4 <= uint64(len(tiff)) {
+		next = self.order.Uint32(tiff[end:])
+	}
+
+	return ifd, next, nil
+}
+
+// State of an EXIF profile being serialized.
+type exifEncoder struct {
+	exif *exifData
+	buf  []byte
+	// Start of the TIFF data in buf.
+	base int
+	// Positions in buf of the MakerNote entries, written last.
+	makerNotes []int
+	// MakerNote values, in the same order.
+	makerNoteEntries []exifEntry
+}
+
+// Private: serializes the profile. MakerNote values often hold absolute
+// offsets, so they are written back at their original offset; when the
+// rewritten IFDs already take that space, the MakerNote is dropped.
+func (self *exifData) encode() []byte {
+	if buf, ok := self.encodeOnce(); ok {
+		return buf
+	}
+
+	self.ifd0.filter(func(ifdTag uint16, tag uint16) bool {
+		return tag == exifTagMakerNote
+	}, 0)
+
+	buf, _ := self.encodeOnce()
+
+	return buf
+}
+
+// Private: serializes the profile, returns false if a MakerNote couldn't be
+// kept at its original offset.
+func (self *exifData) encodeOnce() ([]byte, bool) {
+	enc := &exifEncoder{exif: self}
+
+	if self.header {
+		enc.buf = append(enc.buf, exifHeader...)
+	}
+
+	enc.base = len(enc.buf)
+
+	if self.order == binary.LittleEndian {
+		enc.buf = append(enc.buf, 'I', 'I')
+	} else {
+		enc.buf = append(enc.buf, 'M', 'M')
+	}
+
+	enc.buf = append(enc.buf, make([]byte, 6)...)
+	self.order.PutUint16(enc.buf[enc.base+2:], 42)
+	self.order.PutUint32(enc.buf[enc.base+4:], 8)
+
+	enc.encodeIFD(self.ifd0)
+
+	for i, entry := range enc.makerNoteEntries {
+		if len(enc.buf)-enc.base > int(entry.offset) {
+			return nil, false
+		}
+
+		enc.buf = append(enc.buf, make([]byte, int(entry.offset)-(len(enc.buf)-enc.base))...)
+		enc.buf = append(enc.buf, entry.value...)
+		self.order.PutUint32(enc.buf[enc.makerNotes[i]+8:], entry.offset)
+	}
+
+	if self.ifd1 != nil {
+		if len(enc.buf)%2 == 1 {
+			enc.buf = append(enc.buf, 0)
+		}
+		// IFD0 is right after the TIFF header.
+		next := enc.base + 8 + 2 + 12*len(self.ifd0.entries)
+		offset := enc.encodeIFD(self.ifd1)
+		self.order.PutUint32(enc.buf[next:], offset)
+	}
+
+	return enc.buf, true
+}
+
+// Private: appends a value, padded to an even length, and returns its
+// offset.
+func (self *exifEncoder) appendValue(value []byte) uint32 {
+	offset := uint32(len(self.buf) - self.base)
+	self.buf = append(self.buf, value...)
+	if len(self.buf)%2 == 1 {
+		self.buf = append(self.buf, 0)
+	}
+	return offset
+}
+
+// Private: appends ifd, its values and its sub-IFDs, except for MakerNote
+// values, and returns its offset.
+func (self *exifEncoder) encodeIFD(ifd *exifIFD) uint32 {
+	order := self.exif.order
+
+	entries := append([]exifEntry(nil), ifd.entries...)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].tag < entries[j].tag
+	})
+
+	start := len(self.buf)
+
+	self.buf = append(self.buf, make([]byte, 2+12*len(entries)+4)...)
+
+	order.PutUint16(self.buf[start:], uint16(len(entries)))
+
+	for i, entry := range entries {
+		pos := start + 2 + 12*i
+
+		order.PutUint16(self.buf[pos:], entry.tag)
+		order.PutUint16(self.buf[pos+2:], entry.typ)
+		order.PutUint32(self.buf[pos+4:], entry.count)
+
+		switch {
+		case entry.sub != nil:
+			offset := self.encodeIFD(entry.sub)
+			order.PutUint32(self.buf[pos+8:], offset)
+		case entry.tag == exifTagThumbnailOffset && ifd == self.exif.ifd1:
+			offset := self.appendValue(self.exif.thumbnail)
+			order.PutUint32(self.buf[pos+8:], offset)
+		case len(entry.value) <= 4:
+			copy(self.buf[pos+8:pos+12], entry.value)
+		case entry.tag == exifTagMakerNote:
+			self.makerNotes = append(self.makerNotes, pos)
+			self.makerNoteEntries = append(self.makerNoteEntries, entry)
+		default:
+			offset := self.appendValue(entry.value)
+			order.PutUint32(self.buf[pos+8:], offset)
+		}
+	}
+
+	return uint32(start - self.base)
+}
+
+// Private: returns the entry of ifd for tag, or nil.
+func (self *exifIFD) entry(tag uint16) *exifEntry {
+	for i := range self.entries {
+		if self.entries[i].tag == tag {
+			return &self.entries[i]
+		}
+	}
+	return nil
+}
+
+// Private: removes the entries for which remove returns true, recursively.
+// Sub-IFDs left empty are removed as well.
+func (self *exifIFD) filter(remove func(ifd uint16, tag uint16) bool, ifdTag uint16) {
+	entries := self.entries[:0]
+
+	for _, entry := range self.entries {
+		if remove(ifdTag, entry.tag) {
+			continue
+		}
+
+		if entry.sub != nil {
+			entry.sub.filter(remove, entry.tag)
+
+			if len(entry.sub.entries) == 0 {
+				continue
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	self.entries = entries
+}
+
+// Private: returns the ImageMagick property name of tag in the IFD pointed
+// by ifdTag (0 for IFD0), or an empty string if it's unknown.
+func exifTagName(ifdTag uint16, tag uint16) string {
+	switch ifdTag {
+	case exifTagGPSIFD:
+		return gpsTagNames[tag]
+	case exifTagInteropIFD:
+		return interopTagNames[tag]
+	}
+	return exifTagNames[tag]
+}
+
+// Private: returns the entry named name (as in exifTagNames or gpsTagNames)
+// in IFD0 or its sub-IFDs, or nil.
+func (self *exifIFD) lookup(name string, ifdTag uint16) *exifEntry {
+	for i := range self.entries {
+		entry := &self.entries[i]
+		if exifTagName(ifdTag, entry.tag) == name {
+			return entry
+		}
+		if entry.sub != nil {
+			if found := entry.sub.lookup(name, entry.tag); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Private: returns the value of the ASCII tag named name, trimmed, or an
+// empty string.
+func (self *exifData) text(name string) string {
+	entry := self.ifd0.lookup(name, 0)
+
+	if entry == nil || entry.typ != exifASCII {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+}
+
+// Private: returns the values of the numeric tag named name, with rationals
+// already divided, or nil.
+func (self *exifData) numbers(name string) []float64 {
+	entry := self.ifd0.lookup(name, 0)
+
+	if entry == nil {
+		return nil
+	}
+
+	size := exifTypeSizes[entry.typ]
+	values := make([]float64, 0, len(entry.value)/int(size))
+
+	for raw := entry.value; len(raw) >= int(size); raw = raw[size:] {
+		switch entry.typ {
+		case exifByte:
+			values = append(values, float64(raw[0]))
+		case exifSByte:
+			values = append(values, float64(int8(raw[0])))
+		case exifShort:
+			values = append(values, float64(self.order.Uint16(raw)))
+		case exifSShort:
+			values = append(values, float64(int16(self.order.Uint16(raw))))
+		case exifLong:
+			values = append(values, float64(self.order.Uint32(raw)))
+		case exifSLong:
+			values = append(values, float64(int32(self.order.Uint32(raw))))
+		case exifRational:
+			num, den := self.order.Uint32(raw), self.order.Uint32(raw[4:])
+			if den == 0 {
+				return nil
+			}
+			values = append(values, float64(num)/float64(den))
+		case exifSRational:
+			num, den := int32(self.order.Uint32(raw)), int32(self.order.Uint32(raw[4:]))
+			if den == 0 {
+				return nil
+			}
+			values = append(values, float64(num)/float64(den))
+		case exifFloat:
+			values = append(values, float64(math.Float32frombits(self.order.Uint32(raw))))
+		case exifDouble:
+			values = append(values, math.Float64frombits(self.order.Uint64(raw)))
+		default:
+			return nil
+		}
+	}
+
+	return values
+}
+
+// Private: returns the first value of the numeric tag named name, or 0.
+func (self *exifData) number(name string) float64 {
+	if values := self.numbers(name); len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
+
+// Private: returns the date of the tag named name, with the fraction of
+// second and UTC offset of the tags named subsec and offset, or the zero
+// time. Dates without offset are taken as UTC.
+func (self *exifData) date(name string, subsec string, offset string) time.Time {
+	value := self.text(name)
+
+	if value == "" {
+		return time.Time{}
+	}
+
+	location := time.UTC
+
+	if zone, err := time.Parse("-07:00", self.text(offset)); err == nil {
+		_, seconds := zone.Zone()
+		location = time.FixedZone(self.text(offset), seconds)
+	}
+
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", value, location)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	if digits := self.text(subsec); digits != "" {
+		if fraction, err := strconv.ParseFloat("0."+digits, 64); err == nil {
+			t = t.Add(time.Duration(fraction * float64(time.Second)))
+		}
+	}
+
+	return t
+}
+
+// Private: returns the GPS coordinate of the tag named name in decimal
+// degrees, negative if the tag named name+"Ref" is negative.
+func (self *exifData) coordinate(name string, negative string) (float64, bool) {
+	values := self.numbers(name)
+
+	if len(values) != 3 {
+		return 0, false
+	}
+
+	degrees := values[0] + values[1]/60 + values[2]/3600
+
+	if self.text(name+"Ref") == negative {
+		degrees = -degrees
+	}
+
+	return degrees, true
+}
+
+// Camera and exposure information of an image, read from its EXIF profile
+// by EXIF(). Missing tags are left as zero values.
+type EXIF struct {
+	Make      string
+	Model     string
+	LensMake  string
+	LensModel string
+	Software  string
+	Artist    string
+	Copyright string
+
+	// One of the *_ORIENTATION constants.
+	Orientation uint
+
+	// When the photo was taken and when it was last modified.
+	DateTimeOriginal time.Time
+	DateTime         time.Time
+
+	// Exposure time, in seconds.
+	ExposureTime float64
+	// F-number of the aperture.
+	FNumber float64
+	// Exposure bias, in EV.
+	ExposureBias float64
+	ISO          uint
+	// Focal length, in millimeters, actual and 35mm equivalent.
+	FocalLength           float64
+	FocalLengthIn35mmFilm uint
+	// True if the flash fired.
+	Flash bool
+
+	// True if the image has GPS coordinates.
+	HasGPS bool
+	// Decimal degrees, negative to the south and west.
+	Latitude  float64
+	Longitude float64
+	// Meters above sea level.
+	Altitude float64
+}
+
+// Returns the camera and exposure information of the current image. Images
+// without an EXIF profile return an empty EXIF, profiles that can't be
+// parsed return an error.
+func (self *Canvas) EXIF() (*EXIF, error) {
+	info := &EXIF{}
+
+	data := self.Profile("exif")
+
+	if data == nil {
+		return info, nil
+	}
+
+	exif, err := parseExif(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info.Make = exif.text("Make")
+	info.Model = exif.text("Model")
+	info.LensMake = exif.text("LensMake")
+	info.LensModel = exif.text("LensModel")
+	info.Software = exif.text("Software")
+	info.Artist = exif.text("Artist")
+	info.Copyright = exif.text("Copyright")
+
+	info.Orientation = uint(exif.number("Orientation"))
+
+	info.DateTimeOriginal = exif.date("DateTimeOriginal", "SubSecTimeOriginal", "OffsetTimeOriginal")
+	info.DateTime = exif.date("DateTime", "SubSecTime", "OffsetTime")
+
+	info.ExposureTime = exif.number("ExposureTime")
+	info.FNumber = exif.number("FNumber")
+	info.ExposureBias = exif.number("ExposureBiasValue")
+	info.ISO = uint(exif.number("ISOSpeedRatings"))
+	info.FocalLength = exif.number("FocalLength")
+	info.FocalLengthIn35mmFilm = uint(exif.number("FocalLengthIn35mmFilm"))
+	info.Flash = uint(exif.number("Flash"))&1 == 1
+
+	latitude, hasLatitude := exif.coordinate("GPSLatitude", "S")
+	longitude, hasLongitude := exif.coordinate("GPSLongitude", "W")
+
+	if hasLatitude && hasLongitude {
+		info.HasGPS = true
+		info.Latitude = latitude
+		info.Longitude = longitude
+		info.Altitude = exif.number("GPSAltitude")
+		if exif.number("GPSAltitudeRef") == 1 {
+			info.Altitude = -info.Altitude
+		}
+	}
+
+	return info, nil
+}
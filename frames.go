@@ -0,0 +1,260 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Private: wraps a MagickWand into a new canvas, the canvas takes ownership
+// of the wand.
+func newCanvasFromWand(wand *C.MagickWand) *Canvas {
+	self := New()
+
+	C.DestroyMagickWand(self.wand)
+	self.wand = wand
+
+	return self
+}
+
+// Private: replaces the images of the canvas with the images of wand, which
+// is destroyed. Settings of the canvas' wand (format, options, size...) are
+// kept, and the first image becomes the current one.
+func (self *Canvas) replaceWand(wand *C.MagickWand) {
+	defer C.DestroyMagickWand(wand)
+
+	for C.MagickGetNumberImages(self.wand) > 0 {
+		C.MagickSetFirstIterator(self.wand)
+		C.MagickRemoveImage(self.wand)
+	}
+
+	C.MagickAddImage(self.wand, wand)
+	C.MagickSetFirstIterator(self.wand)
+}
+
+// Returns the number of frames (images) in the canvas. Animated GIFs and
+// multi-page TIFFs may have more than one frame.
+func (self *Canvas) Frames() uint {
+	return uint(C.MagickGetNumberImages(self.wand))
+}
+
+// Returns the index of the current frame. Most canvas methods operate on the
+// current frame only.
+func (self *Canvas) CurrentFrame() uint {
+	return uint(C.MagickGetIteratorIndex(self.wand))
+}
+
+// Makes the i-th frame the current one.
+func (self *Canvas) SetFrame(i uint) error {
+	if i >= self.Frames() {
+		return fmt.Errorf("Could not set frame: index %d out of range.", i)
+	}
+
+	if C.MagickSetIteratorIndex(self.wand, C.ssize_t(i)) == C.MagickFalse {
+		return self.magickError("set frame")
+	}
+
+	return nil
+}
+
+// Returns a new canvas holding a copy of the i-th frame. The current frame
+// is not changed.
+func (self *Canvas) Frame(i uint) (*Canvas, error) {
+	current := self.CurrentFrame()
+
+	if err := self.SetFrame(i); err != nil {
+		return nil, err
+	}
+
+	defer self.SetFrame(current)
+
+	wand := C.MagickGetImage(self.wand)
+
+	if wand == nil {
+		return nil, self.magickError("get frame")
+	}
+
+	return newCanvasFromWand(wand), nil
+}
+
+// Appends a copy of every frame of source after the last frame of the
+// canvas.
+func (self *Canvas) AddFrame(source *Canvas) error {
+	if source.Frames() == 0 {
+		return errors.New("Could not add frame: source canvas is empty.")
+	}
+
+	C.MagickSetLastIterator(self.wand)
+
+	if C.MagickAddImage(self.wand, source.wand) == C.MagickFalse {
+		return self.magickError("add frame")
+	}
+
+	return nil
+}
+
+// Removes the i-th frame.
+func (self *Canvas) RemoveFrame(i uint) error {
+	if err := self.SetFrame(i); err != nil {
+		return err
+	}
+
+	if C.MagickRemoveImage(self.wand) == C.MagickFalse {
+		return self.magickError("remove frame")
+	}
+
+	return nil
+}
+
+// Moves the frame at index from to index to, shifting the frames in
+// between.
+func (self *Canvas) MoveFrame(from uint, to uint) error {
+	n := self.Frames()
+
+	if from >= n || to >= n {
+		return errors.New("Could not move frame: index out of range.")
+	}
+
+	if from == to {
+		return nil
+	}
+
+	order := make([]uint, 0, n)
+
+	for i := uint(0); i < n; i++ {
+		if i != from {
+			order = append(order, i)
+		}
+	}
+
+	order = append(order[:to], append([]uint{from}, order[to:]...)...)
+
+	// Frames are appended one by one to a new wand, as inserting in the
+	// middle of a wand depends on the state of its iterator.
+	wand := C.NewMagickWand()
+
+	for _, i := range order {
+		C.MagickSetIteratorIndex(self.wand, C.ssize_t(i))
+
+		frame := C.MagickGetImage(self.wand)
+
+		if frame == nil {
+			C.DestroyMagickWand(wand)
+			return self.magickError("move frame")
+		}
+
+		C.MagickSetLastIterator(wand)
+		success := C.MagickAddImage(wand, frame)
+		C.DestroyMagickWand(frame)
+
+		if success == C.MagickFalse {
+			C.DestroyMagickWand(wand)
+			return self.magickError("move frame")
+		}
+	}
+
+	self.replaceWand(wand)
+
+	C.MagickSetIteratorIndex(self.wand, C.ssize_t(to))
+
+	return nil
+}
+
+// Sets the time the current frame is displayed, in ticks (1/100th of a
+// second by default).
+func (self *Canvas) SetDelay(ticks uint) error {
+	if C.MagickSetImageDelay(self.wand, C.size_t(ticks)) == C.MagickFalse {
+		return self.magickError("set delay")
+	}
+
+	return nil
+}
+
+// Returns the time the current frame is displayed, in ticks.
+func (self *Canvas) Delay() uint {
+	return uint(C.MagickGetImageDelay(self.wand))
+}
+
+// Sets how many times an animation is played, 0 means forever.
+func (self *Canvas) SetLoop(iterations uint) error {
+	return self.eachImage(func() error {
+		if C.MagickSetImageIterations(self.wand, C.size_t(iterations)) == C.MagickFalse {
+			return self.magickError("set loop")
+		}
+		return nil
+	})
+}
+
+// Returns how many times an animation is played, 0 means forever.
+func (self *Canvas) Loop() uint {
+	return uint(C.MagickGetImageIterations(self.wand))
+}
+
+// Sets the disposal method of the current frame. See the *_DISPOSE
+// constants.
+func (self *Canvas) SetDispose(method uint) error {
+	if C.MagickSetImageDispose(self.wand, C.DisposeType(method)) == C.MagickFalse {
+		return self.magickError("set dispose")
+	}
+
+	return nil
+}
+
+// Returns the disposal method of the current frame.
+func (self *Canvas) Dispose() uint {
+	return uint(C.MagickGetImageDispose(self.wand))
+}
+
+// Private: returns true if the frames of the canvas are an animation, with
+// delays, disposal methods or page offsets, rather than independent pages.
+func (self *Canvas) isAnimation() bool {
+	animation := false
+
+	self.eachImage(func() error {
+		var width, height C.size_t
+		var x, y C.ssize_t
+
+		C.MagickGetImagePage(self.wand, &width, &height, &x, &y)
+
+		if C.MagickGetImageDelay(self.wand) > 0 || C.MagickGetImageDispose(self.wand) != C.UndefinedDispose || x != 0 || y != 0 {
+			animation = true
+		}
+
+		return nil
+	})
+
+	return animation
+}
+
+// Composites every frame over the previous ones so each frame becomes a
+// complete image of the same size. Needed before transforming optimized
+// animations.
+func (self *Canvas) Coalesce() error {
+	wand := C.MagickCoalesceImages(self.wand)
+
+	if wand == nil {
+		return self.magickError("coalesce")
+	}
+
+	self.replaceWand(wand)
+
+	return nil
+}
+
+// Reduces every frame to the smallest area that changed from the previous
+// one. This is the inverse of Coalesce().
+func (self *Canvas) OptimizeLayers() error {
+	wand := C.MagickOptimizeImageLayers(self.wand)
+
+	if wand == nil {
+		return self.magickError("optimize layers")
+	}
+
+	self.replaceWand(wand)
+
+	return nil
+}
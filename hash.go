@@ -0,0 +1,288 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+	"unsafe"
+)
+
+// 64-bit perceptual hash of an image, see AverageHash(), DifferenceHash()
+// and PerceptualHash(). Similar images have hashes that differ in few bits.
+type Hash uint64
+
+// Returns the number of bits that differ between two hashes, 0 for identical
+// images. As a rule of thumb, images within 10 bits are near-duplicates.
+func (self Hash) Distance(other Hash) int {
+	return bits.OnesCount64(uint64(self ^ other))
+}
+
+// Image moments hash: the logarithm of the seven Hu invariant moments of the
+// red, green and blue channels. Moments don't change with scale, rotation
+// nor reflection, see MomentsHash().
+type MomentsHash [3][7]float64
+
+// Returns the sum of the squared differences between two moments hashes, 0
+// for identical images.
+func (self MomentsHash) Distance(other MomentsHash) float64 {
+	var d float64
+
+	for c := range self {
+		for i := range self[c] {
+			d += (self[c][i] - other[c][i]) * (self[c][i] - other[c][i])
+		}
+	}
+
+	return d
+}
+
+// Private: returns the pixels of the current image scaled to width x height
+// in the given channels, from 0 to 1.
+func (self *Canvas) hashPixels(width uint, height uint, channels string) ([]float64, error) {
+	if self.Frames() == 0 {
+		return nil, errors.New("Could not hash image: canvas is empty.")
+	}
+
+	wand := C.MagickGetImage(self.wand)
+
+	if wand == nil {
+		return nil, self.magickError("copy image")
+	}
+
+	scaled := newCanvasFromWand(wand)
+	defer scaled.Destroy()
+
+	if C.MagickResizeImage(scaled.wand, C.size_t(width), C.size_t(height), C.FilterTypes(BOX_FILTER), 1) == C.MagickFalse {
+		return nil, scaled.magickError("resize image")
+	}
+
+	pix := make([]float64, int(width*height)*len(channels))
+
+	if err := scaled.exportPixels(0, 0, width, height, channels, C.DoublePixel, unsafe.Pointer(&pix[0])); err != nil {
+		return nil, err
+	}
+
+	return pix, nil
+}
+
+// Returns the average hash of the current image: each bit tells whether a
+// cell of an 8x8 grayscale version of the image is brighter than the mean.
+func (self *Canvas) AverageHash() (Hash, error) {
+	pix, err := self.hashPixels(8, 8, "I")
+
+	if err != nil {
+		return 0, err
+	}
+
+	var mean float64
+
+	for _, v := range pix {
+		mean += v
+	}
+
+	mean /= float64(len(pix))
+
+	var hash Hash
+
+	for i, v := range pix {
+		if v > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash, nil
+}
+
+// Returns the difference hash of the current image: each bit tells whether
+// a cell of a 9x8 grayscale version of the image is brighter than the cell
+// on its right.
+func (self *Canvas) DifferenceHash() (Hash, error) {
+	pix, err := self.hashPixels(9, 8, "I")
+
+	if err != nil {
+		return 0, err
+	}
+
+	var hash Hash
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pix[y*9+x] > pix[y*9+x+1] {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// Private: returns the 2D DCT-II of a size x size block, keeping only the
+// keep x keep lowest frequencies.
+func dct(block []float64, size int, keep int) []float64 {
+	cosines := make([]float64, keep*size)
+
+	for u := 0; u < keep; u++ {
+		for x := 0; x < size; x++ {
+			cosines[u*size+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*size))
+		}
+	}
+
+	// Rows first, then columns.
+	rows := make([]float64, size*keep)
+
+	for y := 0; y < size; y++ {
+		for u := 0; u < keep; u++ {
+			var sum float64
+			for x := 0; x < size; x++ {
+				sum += block[y*size+x] * cosines[u*size+x]
+			}
+			rows[y*keep+u] = sum
+		}
+	}
+
+	coefficients := make([]float64, keep*keep)
+
+	for v := 0; v < keep; v++ {
+		for u := 0; u < keep; u++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				sum += rows[y*keep+u] * cosines[v*size+y]
+			}
+			coefficients[v*keep+u] = sum
+		}
+	}
+
+	return coefficients
+}
+
+// Returns the pHash of the current image: each bit tells whether one of the
+// 8x8 lowest frequencies of the DCT of a 32x32 grayscale version of the image
+// is above the median. The constant term is left out, its bit is always 0.
+func (self *Canvas) PerceptualHash() (Hash, error) {
+	pix, err := self.hashPixels(32, 32, "I")
+
+	if err != nil {
+		return 0, err
+	}
+
+	coefficients := dct(pix, 32, 8)
+
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+
+	median := sorted[len(sorted)/2]
+
+	var hash Hash
+
+	for i := 1; i < len(coefficients); i++ {
+		if coefficients[i] > median {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash, nil
+}
+
+// Private: returns the seven Hu invariant moments of a width x height
+// channel.
+func huMoments(channel []float64, width int, height int) [7]float64 {
+	var m00, m10, m01 float64
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := channel[y*width+x]
+			m00 += v
+			m10 += float64(x) * v
+			m01 += float64(y) * v
+		}
+	}
+
+	var hu [7]float64
+
+	if m00 == 0 {
+		return hu
+	}
+
+	cx, cy := m10/m00, m01/m00
+
+	var mu20, mu02, mu11, mu30, mu03, mu21, mu12 float64
+
+	for y := 0; y < height; y++ {
+		dy := float64(y) - cy
+		for x := 0; x < width; x++ {
+			dx := float64(x) - cx
+			v := channel[y*width+x]
+			mu20 += dx * dx * v
+			mu02 += dy * dy * v
+			mu11 += dx * dy * v
+			mu30 += dx * dx * dx * v
+			mu03 += dy * dy * dy * v
+			mu21 += dx * dx * dy * v
+			mu12 += dx * dy * dy * v
+		}
+	}
+
+	// Scale invariance.
+	n2 := math.Pow(m00, 2)
+	n3 := math.Pow(m00, 2.5)
+
+	n20, n02, n11 := mu20/n2, mu02/n2, mu11/n2
+	n30, n03, n21, n12 := mu30/n3, mu03/n3, mu21/n3, mu12/n3
+
+	hu[0] = n20 + n02
+	hu[1] = (n20-n02)*(n20-n02) + 4*n11*n11
+	hu[2] = (n30-3*n12)*(n30-3*n12) + (3*n21-n03)*(3*n21-n03)
+	hu[3] = (n30+n12)*(n30+n12) + (n21+n03)*(n21+n03)
+	hu[4] = (n30-3*n12)*(n30+n12)*((n30+n12)*(n30+n12)-3*(n21+n03)*(n21+n03)) +
+		(3*n21-n03)*(n21+n03)*(3*(n30+n12)*(n30+n12)-(n21+n03)*(n21+n03))
+	hu[5] = (n20-n02)*((n30+n12)*(n30+n12)-(n21+n03)*(n21+n03)) +
+		4*n11*(n30+n12)*(n21+n03)
+	hu[6] = (3*n21-n03)*(n30+n12)*((n30+n12)*(n30+n12)-3*(n21+n03)*(n21+n03)) -
+		(n30-3*n12)*(n21+n03)*(3*(n30+n12)*(n30+n12)-(n21+n03)*(n21+n03))
+
+	return hu
+}
+
+// Returns the moments hash of the current image, in the spirit of
+// ImageMagick's perceptual hash. The image is scaled down to at most
+// 256x256 pixels first.
+func (self *Canvas) MomentsHash() (MomentsHash, error) {
+	var hash MomentsHash
+
+	width, height := self.Width(), self.Height()
+
+	if ratio := 256 / math.Max(float64(width), float64(height)); ratio < 1 {
+		width = uint(math.Max(1, math.Round(float64(width)*ratio)))
+		height = uint(math.Max(1, math.Round(float64(height)*ratio)))
+	}
+
+	pix, err := self.hashPixels(width, height, "RGB")
+
+	if err != nil {
+		return hash, err
+	}
+
+	n := int(width * height)
+
+	for c := range hash {
+		channel := make([]float64, n)
+
+		for i := range channel {
+			channel[i] = pix[3*i+c]
+		}
+
+		for i, m := range huMoments(channel, int(width), int(height)) {
+			if m != 0 {
+				hash[c][i] = -math.Copysign(math.Log10(math.Abs(m)), m)
+			}
+		}
+	}
+
+	return hash, nil
+}
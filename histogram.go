@@ -0,0 +1,152 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// A color and the number of pixels that have it.
+type ColorCount struct {
+	Color color.NRGBA
+	Count uint
+}
+
+// Private: returns the color of a pixel wand.
+func pixelNRGBA(p *C.PixelWand) color.NRGBA {
+	return color.NRGBA{
+		R: uint8(math.Round(float64(C.PixelGetRed(p)) * 0xff)),
+		G: uint8(math.Round(float64(C.PixelGetGreen(p)) * 0xff)),
+		B: uint8(math.Round(float64(C.PixelGetBlue(p)) * 0xff)),
+		A: uint8(math.Round(float64(C.PixelGetAlpha(p)) * 0xff)),
+	}
+}
+
+// Returns the unique colors of the current image with the number of pixels
+// of each one, most frequent first.
+func (self *Canvas) Histogram() ([]ColorCount, error) {
+	if self.Frames() == 0 {
+		return nil, errors.New("Could not compute histogram: canvas is empty.")
+	}
+
+	var n C.size_t
+
+	wands := C.MagickGetImageHistogram(self.wand, &n)
+
+	if wands == nil {
+		return nil, self.magickError("compute histogram")
+	}
+
+	defer C.DestroyPixelWands(wands, n)
+
+	histogram := make([]ColorCount, 0, int(n))
+
+	for _, wand := range unsafe.Slice(wands, int(n)) {
+		histogram = append(histogram, ColorCount{
+			Color: pixelNRGBA(wand),
+			Count: uint(C.PixelGetColorCount(wand)),
+		})
+	}
+
+	sort.SliceStable(histogram, func(i, j int) bool {
+		return histogram[i].Count > histogram[j].Count
+	})
+
+	return histogram, nil
+}
+
+// Returns up to k colors that best represent the current image, most
+// frequent first, with the number of pixels each one stands for. Colors are
+// found by quantizing a copy of the image, which is sampled down to at most
+// 256x256 pixels first so counts are relative.
+func (self *Canvas) DominantColors(k int) ([]ColorCount, error) {
+	if k < 1 {
+		return nil, errors.New("Could not compute dominant colors: k must be at least 1.")
+	}
+
+	if self.Frames() == 0 {
+		return nil, errors.New("Could not compute dominant colors: canvas is empty.")
+	}
+
+	wand := C.MagickGetImage(self.wand)
+
+	if wand == nil {
+		return nil, self.magickError("copy image")
+	}
+
+	sample := newCanvasFromWand(wand)
+	defer sample.Destroy()
+
+	width, height := sample.Width(), sample.Height()
+
+	if ratio := 256 / math.Max(float64(width), float64(height)); ratio < 1 {
+		width = uint(math.Max(1, math.Round(float64(width)*ratio)))
+		height = uint(math.Max(1, math.Round(float64(height)*ratio)))
+
+		if C.MagickSampleImage(sample.wand, C.size_t(width), C.size_t(height)) == C.MagickFalse {
+			return nil, sample.magickError("sample image")
+		}
+	}
+
+	if C.MagickQuantizeImage(sample.wand, C.size_t(k), C.UndefinedColorspace, 0, C.MagickFalse, C.MagickFalse) == C.MagickFalse {
+		return nil, sample.magickError("quantize image")
+	}
+
+	histogram, err := sample.Histogram()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(histogram) > k {
+		histogram = histogram[:k]
+	}
+
+	return histogram, nil
+}
+
+// Returns the average color of the current image. Color channels are
+// weighted by alpha, so fully transparent pixels don't count.
+func (self *Canvas) AverageColor() (color.NRGBA, error) {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return color.NRGBA{}, errors.New("Could not compute average color: canvas is empty.")
+	}
+
+	// Row by row, so memory doesn't grow with the image.
+	pix := make([]float64, 4*width)
+
+	var r, g, b, a float64
+
+	for y := 0; y < int(height); y++ {
+		if err := self.exportPixels(0, y, width, 1, "RGBA", C.DoublePixel, unsafe.Pointer(&pix[0])); err != nil {
+			return color.NRGBA{}, err
+		}
+
+		for i := 0; i < len(pix); i += 4 {
+			r += pix[i] * pix[i+3]
+			g += pix[i+1] * pix[i+3]
+			b += pix[i+2] * pix[i+3]
+			a += pix[i+3]
+		}
+	}
+
+	if a == 0 {
+		return color.NRGBA{}, nil
+	}
+
+	return color.NRGBA{
+		R: uint8(math.Round(r / a * 0xff)),
+		G: uint8(math.Round(g / a * 0xff)),
+		B: uint8(math.Round(b / a * 0xff)),
+		A: uint8(math.Round(a / float64(width*height) * 0xff)),
+	}, nil
+}
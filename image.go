@@ -0,0 +1,195 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"unsafe"
+)
+
+// Private: copies the pixels of the rectangle (x, y, width, height) into ptr,
+// which must be big enough to hold them in the given map and storage.
+func (self *Canvas) exportPixels(x, y int, width, height uint, channels string, storage C.StorageType, ptr unsafe.Pointer) error {
+	cmap := C.CString(channels)
+	defer C.free(unsafe.Pointer(cmap))
+
+	status := C.MagickExportImagePixels(self.wand, C.ssize_t(x), C.ssize_t(y), C.size_t(width), C.size_t(height), cmap, storage, ptr)
+
+	if status == C.MagickFalse {
+		return self.magickError("export pixels")
+	}
+
+	return nil
+}
+
+// Private: replaces the pixels of the rectangle (x, y, width, height) with
+// the ones stored at ptr in the given map and storage.
+func (self *Canvas) importPixels(x, y int, width, height uint, channels string, storage C.StorageType, ptr unsafe.Pointer) error {
+	cmap := C.CString(channels)
+	defer C.free(unsafe.Pointer(cmap))
+
+	status := C.MagickImportImagePixels(self.wand, C.ssize_t(x), C.ssize_t(y), C.size_t(width), C.size_t(height), cmap, storage, ptr)
+
+	if status == C.MagickFalse {
+		return self.magickError("import pixels")
+	}
+
+	return nil
+}
+
+// Returns a copy of the canvas as an image.Image. Grayscale canvases without
+// alpha become *image.Gray, canvases deeper than 8 bits become
+// *image.NRGBA64 and everything else becomes *image.RGBA.
+func (self *Canvas) ToImage() (image.Image, error) {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return nil, errors.New("Could not convert to image: canvas is empty.")
+	}
+
+	rect := image.Rect(0, 0, int(width), int(height))
+
+	alpha := C.MagickGetImageAlphaChannel(self.wand) == C.MagickTrue
+	depth := uint(C.MagickGetImageDepth(self.wand))
+	imageType := self.Type()
+	gray := imageType == GRAYSCALE_TYPE || imageType == BILEVEL_TYPE
+
+	switch {
+	case gray && !alpha && depth <= 8:
+		img := image.NewGray(rect)
+		if err := self.exportPixels(0, 0, width, height, "I", C.CharPixel, unsafe.Pointer(&img.Pix[0])); err != nil {
+			return nil, err
+		}
+		return img, nil
+
+	case depth > 8:
+		buf := make([]uint16, 4*width*height)
+		if err := self.exportPixels(0, 0, width, height, "RGBA", C.ShortPixel, unsafe.Pointer(&buf[0])); err != nil {
+			return nil, err
+		}
+		img := image.NewNRGBA64(rect)
+		for i, v := range buf {
+			img.Pix[2*i] = uint8(v >> 8)
+			img.Pix[2*i+1] = uint8(v)
+		}
+		return img, nil
+	}
+
+	img := image.NewRGBA(rect)
+
+	if err := self.exportPixels(0, 0, width, height, "RGBA", C.CharPixel, unsafe.Pointer(&img.Pix[0])); err != nil {
+		return nil, err
+	}
+
+	if alpha {
+		// MagickWand exports straight alpha, image.RGBA is premultiplied.
+		for i := 0; i < len(img.Pix); i += 4 {
+			a := uint32(img.Pix[i+3])
+			if a == 0xff {
+				continue
+			}
+			img.Pix[i] = uint8(uint32(img.Pix[i]) * a / 0xff)
+			img.Pix[i+1] = uint8(uint32(img.Pix[i+1]) * a / 0xff)
+			img.Pix[i+2] = uint8(uint32(img.Pix[i+2]) * a / 0xff)
+		}
+	}
+
+	return img, nil
+}
+
+// Private: returns the pixels of rect as a contiguous buffer, taking care of
+// strides and sub-images.
+func packPixels(pix []uint8, stride int, rect image.Rectangle, bytesPerPixel int) []uint8 {
+	rowLength := rect.Dx() * bytesPerPixel
+
+	if stride == rowLength && len(pix) == rowLength*rect.Dy() {
+		return pix
+	}
+
+	packed := make([]uint8, 0, rowLength*rect.Dy())
+
+	for y := 0; y < rect.Dy(); y++ {
+		offset := y * stride
+		packed = append(packed, pix[offset:offset+rowLength]...)
+	}
+
+	return packed
+}
+
+// Creates a new canvas from the pixels of img. The caller is responsible for
+// destroying the returned canvas.
+func FromImage(img image.Image) (*Canvas, error) {
+	bounds := img.Bounds()
+
+	if bounds.Empty() {
+		return nil, errors.New("Could not convert from image: image is empty.")
+	}
+
+	width, height := uint(bounds.Dx()), uint(bounds.Dy())
+
+	self := New()
+
+	if _, isGray := img.(*image.Gray); isGray {
+		self.SetBackgroundColor("#000000")
+	}
+
+	if err := self.Blank(width, height); err != nil {
+		self.Destroy()
+		return nil, err
+	}
+
+	var err error
+
+	switch src := img.(type) {
+	case *image.Gray:
+		pix := packPixels(src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], src.Stride, bounds, 1)
+		if err = self.importPixels(0, 0, width, height, "I", C.CharPixel, unsafe.Pointer(&pix[0])); err == nil {
+			err = self.SetType(GRAYSCALE_TYPE)
+		}
+
+	case *image.NRGBA:
+		pix := packPixels(src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], src.Stride, bounds, 4)
+		err = self.importPixels(0, 0, width, height, "RGBA", C.CharPixel, unsafe.Pointer(&pix[0]))
+
+	case *image.NRGBA64:
+		// Components are stored big-endian.
+		packed := packPixels(src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], src.Stride, bounds, 8)
+		pix := make([]uint16, len(packed)/2)
+		for i := range pix {
+			pix[i] = uint16(packed[2*i])<<8 | uint16(packed[2*i+1])
+		}
+		err = self.importPixels(0, 0, width, height, "RGBA", C.ShortPixel, unsafe.Pointer(&pix[0]))
+
+	case *image.RGBA:
+		pix := make([]uint8, 0, 4*width*height)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(src.RGBAAt(x, y)).(color.NRGBA)
+				pix = append(pix, c.R, c.G, c.B, c.A)
+			}
+		}
+		err = self.importPixels(0, 0, width, height, "RGBA", C.CharPixel, unsafe.Pointer(&pix[0]))
+
+	default:
+		pix := make([]uint16, 0, 4*width*height)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBA64Model.Convert(src.At(x, y)).(color.NRGBA64)
+				pix = append(pix, c.R, c.G, c.B, c.A)
+			}
+		}
+		err = self.importPixels(0, 0, width, height, "RGBA", C.ShortPixel, unsafe.Pointer(&pix[0]))
+	}
+
+	if err != nil {
+		self.Destroy()
+		return nil, err
+	}
+
+	return self, nil
+}
@@ -0,0 +1,178 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Marker of IPTC datasets.
+const iptcMarker = 0x1c
+
+// Photoshop image resource that holds IPTC datasets.
+const iptcResourceID = 0x0404
+
+// Editorial information of an image, read from its IPTC profile by IPTC().
+// Missing datasets are left as zero values.
+type IPTC struct {
+	ObjectName          string
+	Headline            string
+	Caption             string
+	Keywords            []string
+	Byline              []string
+	BylineTitle         string
+	Credit              string
+	Source              string
+	CopyrightNotice     string
+	SpecialInstructions string
+	Writer              string
+
+	City          string
+	SubLocation   string
+	ProvinceState string
+	CountryCode   string
+	Country       string
+
+	// Date and time the content was created.
+	DateCreated time.Time
+}
+
+// Private: extracts the IPTC datasets of a Photoshop resource block, or nil.
+func iptcFromResources(data []byte) []byte {
+	for len(data) >= 12 && bytes.HasPrefix(data, []byte("8BIM")) {
+		id := binary.BigEndian.Uint16(data[4:])
+
+		// Pascal name, padded to an even length.
+		nameLength := int(data[6]) + 1
+		nameLength += nameLength % 2
+
+		if 6+nameLength+4 > len(data) {
+			return nil
+		}
+
+		size := int(binary.BigEndian.Uint32(data[6+nameLength:]))
+		start := 6 + nameLength + 4
+
+		if size < 0 || start+size > len(data) {
+			return nil
+		}
+
+		if id == iptcResourceID {
+			return data[start : start+size]
+		}
+
+		data = data[start+size+size%2:]
+	}
+
+	return nil
+}
+
+// Private: returns the datasets of an IPTC profile keyed by record and
+// dataset number, e.g. 0x0219 for 2:25 (keywords).
+func parseIPTC(data []byte) map[uint16][]string {
+	datasets := map[uint16][]string{}
+
+	var raw [][]byte
+	var keys []uint16
+
+	for len(data) >= 5 && data[0] == iptcMarker {
+		key := binary.BigEndian.Uint16(data[1:])
+		size := int(binary.BigEndian.Uint16(data[3:]))
+		data = data[5:]
+
+		// Extended datasets are not used by editorial fields.
+		if size&0x8000 != 0 || size > len(data) {
+			break
+		}
+
+		keys = append(keys, key)
+		raw = append(raw, data[:size])
+
+		data = data[size:]
+	}
+
+	// 1:90 is the coded character set, ESC % G for UTF-8.
+	utf8Declared := false
+
+	for i, key := range keys {
+		if key == 0x015a && bytes.Equal(raw[i], []byte("\x1b%G")) {
+			utf8Declared = true
+		}
+	}
+
+	for i, key := range keys {
+		value := raw[i]
+
+		var text string
+
+		if utf8Declared || utf8.Valid(value) {
+			text = string(value)
+		} else {
+			// Latin-1, the usual choice of older software.
+			runes := make([]rune, len(value))
+			for j, b := range value {
+				runes[j] = rune(b)
+			}
+			text = string(runes)
+		}
+
+		datasets[key] = append(datasets[key], strings.TrimSpace(strings.TrimRight(text, "\x00")))
+	}
+
+	return datasets
+}
+
+// Returns the editorial information of the current image. Images without an
+// IPTC profile return an empty IPTC.
+func (self *Canvas) IPTC() *IPTC {
+	info := &IPTC{}
+
+	data := self.Profile("iptc")
+
+	if data == nil {
+		data = iptcFromResources(self.Profile("8bim"))
+	}
+
+	if data == nil {
+		return info
+	}
+
+	datasets := parseIPTC(data)
+
+	first := func(key uint16) string {
+		if values := datasets[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	info.ObjectName = first(0x0205)
+	info.Keywords = datasets[0x0219]
+	info.SpecialInstructions = first(0x0228)
+	info.Byline = datasets[0x0250]
+	info.BylineTitle = first(0x0255)
+	info.City = first(0x025a)
+	info.SubLocation = first(0x025c)
+	info.ProvinceState = first(0x025f)
+	info.CountryCode = first(0x0264)
+	info.Country = first(0x0265)
+	info.Headline = first(0x0269)
+	info.Credit = first(0x026e)
+	info.Source = first(0x0273)
+	info.CopyrightNotice = first(0x0274)
+	info.Caption = first(0x0278)
+	info.Writer = first(0x027a)
+
+	// 2:55 is CCYYMMDD and 2:60 HHMMSS±HHMM.
+	if date := first(0x0237); date != "" {
+		if t, err := time.Parse("20060102150405-0700", date+first(0x023c)); err == nil {
+			info.DateCreated = t
+		} else if t, err := time.Parse("20060102", date); err == nil {
+			info.DateCreated = t
+		}
+	}
+
+	return info
+}
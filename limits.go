@@ -0,0 +1,175 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// Sets a process-wide MagickWand resource limit, see the *_RESOURCE
+// constants. Memory, map, disk and area limits are in bytes, time is in
+// seconds. These limits are shared by every canvas.
+func SetResourceLimit(resource uint, limit uint64) error {
+	if C.MagickSetResourceLimit(C.ResourceType(resource), C.MagickSizeType(limit)) == C.MagickFalse {
+		return fmt.Errorf("Could not set resource limit %d to %d.", resource, limit)
+	}
+
+	return nil
+}
+
+// Returns a process-wide MagickWand resource limit.
+func ResourceLimit(resource uint) uint64 {
+	return uint64(C.MagickGetResourceLimit(C.ResourceType(resource)))
+}
+
+// Dimension limits that are checked before an image is decoded, a zero
+// value means no limit.
+type Limits struct {
+	// Maximum width of any frame.
+	Width uint
+	// Maximum height of any frame.
+	Height uint
+	// Maximum number of pixels, summed over every frame.
+	Pixels uint64
+}
+
+// Error returned when an image exceeds the configured Limits. It matches
+// ErrResourceLimit with errors.Is.
+type LimitError struct {
+	Width  uint
+	Height uint
+	Frames uint
+	Pixels uint64
+	Limits Limits
+}
+
+func (self *LimitError) Error() string {
+	return fmt.Sprintf("Could not open image: %dx%d image with %d frame(s) exceeds limits (width %d, height %d, pixels %d).",
+		self.Width, self.Height, self.Frames, self.Limits.Width, self.Limits.Height, self.Limits.Pixels)
+}
+
+func (self *LimitError) Unwrap() error {
+	return ErrResourceLimit
+}
+
+var (
+	defaultLimits     Limits
+	defaultLimitsLock sync.RWMutex
+)
+
+// Sets the limits used by canvases that don't have their own. Safe to call
+// while other goroutines open images.
+func SetDefaultLimits(limits Limits) {
+	defaultLimitsLock.Lock()
+	defer defaultLimitsLock.Unlock()
+	defaultLimits = limits
+}
+
+// Returns the limits used by canvases that don't have their own.
+func DefaultLimits() Limits {
+	defaultLimitsLock.RLock()
+	defer defaultLimitsLock.RUnlock()
+	return defaultLimits
+}
+
+// Sets the limits checked by Open(), OpenBlob() and ReadFrom() before
+// decoding an image, overriding the default ones. Only dimensions can be
+// limited per canvas: memory, map, disk, thread and time limits are enforced
+// by ImageMagick for the whole process, see SetResourceLimit().
+func (self *Canvas) SetLimits(limits Limits) {
+	self.limits = &limits
+}
+
+// Returns the limits checked before decoding an image.
+func (self *Canvas) Limits() Limits {
+	if self.limits != nil {
+		return *self.limits
+	}
+	return DefaultLimits()
+}
+
+// Private: returns true if any limit is set.
+func (self Limits) enabled() bool {
+	return self.Width > 0 || self.Height > 0 || self.Pixels > 0
+}
+
+// Private: checks every frame of a pinged canvas against the limits.
+func (self Limits) check(probe *Canvas) error {
+	var pixels uint64
+	var width, height uint
+
+	probe.eachImage(func() error {
+		w, h := probe.Width(), probe.Height()
+		if w > width {
+			width = w
+		}
+		if h > height {
+			height = h
+		}
+		pixels += uint64(w) * uint64(h)
+		return nil
+	})
+
+	if (self.Width > 0 && width > self.Width) || (self.Height > 0 && height > self.Height) || (self.Pixels > 0 && pixels > self.Pixels) {
+		return &LimitError{
+			Width:  width,
+			Height: height,
+			Frames: probe.Frames(),
+			Pixels: pixels,
+			Limits: self,
+		}
+	}
+
+	return nil
+}
+
+// Private: pings an image file and checks it against the canvas' limits.
+func (self *Canvas) checkFileLimits(filename string) error {
+	limits := self.Limits()
+
+	if !limits.enabled() {
+		return nil
+	}
+
+	probe := New()
+	defer probe.Destroy()
+
+	cfilename := C.CString(filename)
+	status := C.MagickPingImage(probe.wand, cfilename)
+	C.free(unsafe.Pointer(cfilename))
+
+	if status == C.MagickFalse {
+		return probe.magickError(fmt.Sprintf(`open image "%s"`, filename))
+	}
+
+	return limits.check(probe)
+}
+
+// Private: pings an image blob and checks it against the canvas' limits.
+func (self *Canvas) checkBlobLimits(blob []byte, format string) error {
+	limits := self.Limits()
+
+	if !limits.enabled() {
+		return nil
+	}
+
+	probe := New()
+	defer probe.Destroy()
+
+	if format != "" {
+		cformat := C.CString(format)
+		C.MagickSetFormat(probe.wand, cformat)
+		C.free(unsafe.Pointer(cformat))
+	}
+
+	if C.MagickPingImageBlob(probe.wand, unsafe.Pointer(&blob[0]), C.size_t(len(blob))) == C.MagickFalse {
+		return probe.magickError("open image from blob")
+	}
+
+	return limits.check(probe)
+}
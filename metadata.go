@@ -0,0 +1,298 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unsafe"
+)
+
+// What StripWithOptions() keeps. Everything else is removed: EXIF tags
+// (including GPS coordinates, serial numbers, maker notes and the embedded
+// thumbnail), IPTC and XMP profiles, comments and any other profile.
+type StripOptions struct {
+	// Keeps the ICC profile, so colors don't shift.
+	KeepICC bool
+	// Keeps the EXIF orientation, so photos aren't displayed rotated.
+	KeepOrientation bool
+	// Keeps the EXIF copyright and artist.
+	KeepCopyright bool
+	// Keeps the EXIF GPS tags.
+	KeepGPS bool
+	// Other EXIF tags to keep, by name (e.g. "DateTimeOriginal" or "Make").
+	KeepEXIFTags []string
+	// Other profiles to keep, by name (e.g. "xmp" or "iptc").
+	KeepProfiles []string
+}
+
+// Private: returns true if key matches any of patterns, see RemoveMetadata().
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Private: returns the "exif:*" property names of the tags of ifd.
+func (self *exifIFD) propertyNames(ifdTag uint16, names map[string]bool) {
+	for _, entry := range self.entries {
+		if name := exifTagName(ifdTag, entry.tag); name != "" {
+			names["exif:"+name] = true
+		}
+		if entry.sub != nil {
+			entry.sub.propertyNames(entry.tag, names)
+		}
+	}
+}
+
+// Private: deletes a property of the current image.
+func (self *Canvas) deleteProperty(key string) {
+	ckey := C.CString(key)
+	defer C.free(unsafe.Pointer(ckey))
+	C.MagickDeleteImageProperty(self.wand, ckey)
+}
+
+// Private: replaces the EXIF profile of the current image, removing it if
+// exif is empty.
+func (self *Canvas) setExif(exif *exifData) error {
+	if len(exif.ifd0.entries) == 0 {
+		self.removeProfile("exif")
+		return nil
+	}
+	return self.SetProfile("exif", exif.encode())
+}
+
+// Removes profiles and comments from every frame like Strip(), except for
+// what options ask to keep.
+func (self *Canvas) StripWithOptions(options StripOptions) error {
+	return self.eachImage(func() error {
+		profiles := map[string][]byte{}
+
+		for _, name := range options.KeepProfiles {
+			if data := self.Profile(name); data != nil {
+				profiles[name] = data
+			}
+		}
+
+		if options.KeepICC {
+			if data := self.Profile("icc"); data != nil {
+				profiles["icc"] = data
+			}
+		}
+
+		var exif *exifData
+
+		if data := self.Profile("exif"); data != nil {
+			// A profile that can't be parsed is dropped.
+			if parsed, err := parseExif(data); err == nil {
+				exif = parsed
+			}
+		}
+
+		kept := map[string]bool{}
+
+		if exif != nil {
+			exif.ifd1, exif.thumbnail = nil, nil
+
+			exif.ifd0.filter(func(ifdTag uint16, tag uint16) bool {
+				switch {
+				case tag == exifTagGPSIFD:
+					return !options.KeepGPS
+				case tag == exifTagInteropIFD:
+					return true
+				case isSubIFDTag(tag):
+					return false
+				case ifdTag == exifTagGPSIFD:
+					return false
+				case tag == exifTagOrientation && ifdTag == 0:
+					return !options.KeepOrientation
+				case (tag == exifTagCopyright || tag == exifTagArtist) && ifdTag == 0:
+					return !options.KeepCopyright
+				}
+				name := exifTagName(ifdTag, tag)
+				for _, keep := range options.KeepEXIFTags {
+					if name != "" && name == strings.TrimPrefix(keep, "exif:") {
+						return false
+					}
+				}
+				return true
+			}, 0)
+
+			exif.ifd0.propertyNames(0, kept)
+		}
+
+		if err := self.Strip(); err != nil {
+			return err
+		}
+
+		for name, data := range profiles {
+			if err := self.SetProfile(name, data); err != nil {
+				return err
+			}
+		}
+
+		if exif != nil {
+			if err := self.setExif(exif); err != nil {
+				return err
+			}
+		}
+
+		// Properties read from the EXIF profile survive stripping.
+		for key := range self.Metadata() {
+			if strings.HasPrefix(key, "exif:") && !kept[key] {
+				self.deleteProperty(key)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Private: returns true if name is the name of an EXIF tag known to this
+// package, or "unknown", the name ImageMagick gives to the others.
+func isExifTagName(name string) bool {
+	if name == "unknown" {
+		return true
+	}
+	for _, names := range []map[uint16]string{exifTagNames, gpsTagNames, interopTagNames} {
+		for _, known := range names {
+			if known == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Private: returns true if keys match the MakerNote or any serial number
+// tag. Maker notes often hold the serial number of the camera too.
+func removesMakerNote(keys []string) bool {
+	for _, name := range []string{"MakerNote", "SerialNumber", "BodySerialNumber", "LensSerialNumber", "InternalSerialNumber"} {
+		if matchAny(keys, "exif:"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes the metadata properties matching keys from every frame. Keys are
+// property names as returned by Metadata() and may contain wildcards (e.g.
+// "exif:GPS*" or "exif:*SerialNumber"). Matching EXIF tags are removed from
+// the EXIF profile too, so they aren't written back; "exif:unknown" stands
+// for the tags ImageMagick has no name for. An error is returned, before
+// anything is removed, if keys match an "exif:*" property whose tag can't be
+// told.
+//
+// The rest of the profile is kept, including the thumbnail. The MakerNote is
+// removed when keys match it or a serial number tag (e.g.
+// "exif:*SerialNumber"), as cameras store their serial number there too.
+// Otherwise it's kept at its original offset, as it may hold absolute
+// offsets, or dropped if the rewritten profile leaves no room for it there.
+func (self *Canvas) RemoveMetadata(keys ...string) error {
+	return self.eachImage(func() error {
+		properties := self.Metadata()
+
+		for key := range properties {
+			// Newer ImageMagick versions name the tags of the thumbnail IFD
+			// "exif:thumbnail:*".
+			name := strings.TrimPrefix(strings.TrimPrefix(key, "exif:"), "thumbnail:")
+			if matchAny(keys, key) && strings.HasPrefix(key, "exif:") && !isExifTagName(name) {
+				return fmt.Errorf(`Could not remove metadata: unknown EXIF tag for "%s".`, key)
+			}
+		}
+
+		var exif *exifData
+
+		if data := self.Profile("exif"); data != nil {
+			parsed, err := parseExif(data)
+
+			if err != nil {
+				return err
+			}
+
+			exif = parsed
+		}
+
+		for key := range properties {
+			if matchAny(keys, key) {
+				self.deleteProperty(key)
+			}
+		}
+
+		if exif == nil {
+			return nil
+		}
+
+		makerNote := removesMakerNote(keys)
+		changed := false
+
+		remove := func(prefix string) func(uint16, uint16) bool {
+			return func(ifdTag uint16, tag uint16) bool {
+				name := exifTagName(ifdTag, tag)
+				if name == "" {
+					name = "unknown"
+				}
+				removed := matchAny(keys, "exif:"+name) || matchAny(keys, prefix+name) || (makerNote && tag == exifTagMakerNote && ifdTag == exifTagExifIFD)
+				changed = changed || removed
+				return removed
+			}
+		}
+
+		exif.ifd0.filter(remove("exif:"), 0)
+
+		if exif.ifd1 != nil {
+			exif.ifd1.filter(remove("exif:thumbnail:"), 0)
+
+			// The thumbnail can't be found without both tags.
+			if exif.ifd1.entry(exifTagThumbnailOffset) == nil || exif.ifd1.entry(exifTagThumbnailLength) == nil {
+				exif.ifd1, exif.thumbnail = nil, nil
+			}
+		}
+
+		if !changed {
+			return nil
+		}
+
+		return self.setExif(exif)
+	})
+}
+
+// Returns the XMP packet of the current image, or nil if it doesn't have one.
+func (self *Canvas) XMP() []byte {
+	return self.Profile("xmp")
+}
+
+// Embeds packet as the XMP metadata of the current image, replacing the
+// existing one. The packet must be well-formed XML.
+func (self *Canvas) SetXMP(packet []byte) error {
+	if len(packet) == 0 {
+		return errors.New("Could not set XMP: packet is empty.")
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+
+	for {
+		_, err := decoder.Token()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Could not set XMP: %s", err)
+		}
+	}
+
+	return self.SetProfile("xmp", packet)
+}
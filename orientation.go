@@ -0,0 +1,133 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Returns the orientation of the current image, one of the *_ORIENTATION
+// constants, read from the image header, then from its EXIF profile and then
+// from its "exif:Orientation" property. UNDEFINED_ORIENTATION means the image
+// doesn't tell.
+func (self *Canvas) Orientation() uint {
+	orientation := uint(C.MagickGetImageOrientation(self.wand))
+
+	if orientation >= TOP_LEFT_ORIENTATION && orientation <= LEFT_BOTTOM_ORIENTATION {
+		return orientation
+	}
+
+	if data := self.Profile("exif"); data != nil {
+		if exif, err := parseExif(data); err == nil {
+			orientation = uint(exif.number("Orientation"))
+			if orientation >= TOP_LEFT_ORIENTATION && orientation <= LEFT_BOTTOM_ORIENTATION {
+				return orientation
+			}
+		}
+	}
+
+	if value, err := strconv.Atoi(self.Metadata()["exif:Orientation"]); err == nil {
+		orientation = uint(value)
+		if orientation >= TOP_LEFT_ORIENTATION && orientation <= LEFT_BOTTOM_ORIENTATION {
+			return orientation
+		}
+	}
+
+	return UNDEFINED_ORIENTATION
+}
+
+// Private: sets the orientation of the current image in its header, its
+// "exif:Orientation" property and its EXIF profile. A profile that can't be
+// parsed is left as is.
+func (self *Canvas) setOrientation(orientation uint) error {
+	if C.MagickSetImageOrientation(self.wand, C.OrientationType(orientation)) == C.MagickFalse {
+		return self.magickError("set orientation")
+	}
+
+	if err := self.SetMetadata("exif:Orientation", strconv.Itoa(int(orientation))); err != nil {
+		return err
+	}
+
+	data := self.Profile("exif")
+
+	if data == nil {
+		return nil
+	}
+
+	exif, err := parseExif(data)
+
+	if err != nil {
+		return nil
+	}
+
+	value := make([]byte, 2)
+	exif.order.PutUint16(value, uint16(orientation))
+
+	if entry := exif.ifd0.entry(exifTagOrientation); entry != nil {
+		entry.typ, entry.count, entry.value = exifShort, 1, value
+	} else {
+		exif.ifd0.entries = append(exif.ifd0.entries, exifEntry{tag: exifTagOrientation, typ: exifShort, count: 1, value: value})
+	}
+
+	return self.setExif(exif)
+}
+
+// Sets the orientation of every frame, one of the *_ORIENTATION constants,
+// without modifying pixels. Both the image header and the EXIF metadata are
+// updated; rewriting the EXIF profile may drop its MakerNote, see
+// RemoveMetadata().
+func (self *Canvas) SetOrientation(orientation uint) error {
+	if orientation < TOP_LEFT_ORIENTATION || orientation > LEFT_BOTTOM_ORIENTATION {
+		return fmt.Errorf("Could not set orientation: %d is not a valid orientation.", orientation)
+	}
+
+	return self.eachImage(func() error {
+		return self.setOrientation(orientation)
+	})
+}
+
+// Private: applies the transform that displays the current image upright
+// according to orientation.
+func (self *Canvas) orientate(orientation uint) C.MagickBooleanType {
+	switch orientation {
+	case TOP_RIGHT_ORIENTATION:
+		return C.MagickFlopImage(self.wand)
+	case BOTTOM_RIGHT_ORIENTATION:
+		return C.MagickRotateImage(self.wand, self.bg, 180)
+	case BOTTOM_LEFT_ORIENTATION:
+		return C.MagickFlipImage(self.wand)
+	case LEFT_TOP_ORIENTATION:
+		return C.MagickTransposeImage(self.wand)
+	case RIGHT_TOP_ORIENTATION:
+		return C.MagickRotateImage(self.wand, self.bg, 90)
+	case RIGHT_BOTTOM_ORIENTATION:
+		return C.MagickTransverseImage(self.wand)
+	case LEFT_BOTTOM_ORIENTATION:
+		return C.MagickRotateImage(self.wand, self.bg, 270)
+	}
+	return C.MagickTrue
+}
+
+// Rotates and mirrors every frame so it's displayed upright without
+// relying on its orientation metadata, which is then reset to
+// TOP_LEFT_ORIENTATION as SetOrientation() does. Frames without orientation
+// are left untouched.
+func (self *Canvas) AutoOrientate() error {
+	return self.eachImage(func() error {
+		orientation := self.Orientation()
+
+		if orientation == UNDEFINED_ORIENTATION || orientation == TOP_LEFT_ORIENTATION {
+			return nil
+		}
+
+		if self.orientate(orientation) == C.MagickFalse {
+			return self.magickError("orientate image")
+		}
+
+		return self.setOrientation(TOP_LEFT_ORIENTATION)
+	})
+}
@@ -0,0 +1,477 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// A Paint can be used instead of a solid color to fill or stroke shapes and
+// to fill blank canvases. See LinearGradient, RadialGradient and Pattern.
+type Paint interface {
+	// Renders the paint on a new canvas of the given size.
+	render(width uint, height uint) (*Canvas, error)
+	// Draws the paint over a width x height area of the drawing surface of c.
+	draw(c *Canvas, width uint, height uint) error
+}
+
+type gradientStop struct {
+	offset float64
+	// Premultiplied color components.
+	r, g, b, a float64
+}
+
+type gradient struct {
+	stops []gradientStop
+}
+
+// Linear gradient along the line that goes from (X1, Y1) to (X2, Y2).
+type LinearGradient struct {
+	gradient
+	X1 float64
+	Y1 float64
+	X2 float64
+	Y2 float64
+}
+
+// Radial gradient centered at (CX, CY) that reaches its last stop at Radius.
+type RadialGradient struct {
+	gradient
+	CX     float64
+	CY     float64
+	Radius float64
+}
+
+// Tiles the image of a canvas.
+type Pattern struct {
+	source *Canvas
+}
+
+// Private: returns the RGBA components of a color name (e.g. "#ff0000" or
+// "rgba(255,0,0,0.5)"), ranging from 0 to 1.
+func parseColor(value string) (r, g, b, a float64, err error) {
+	wand := C.NewPixelWand()
+	defer C.DestroyPixelWand(wand)
+
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+
+	if C.PixelSetColor(wand, cvalue) == C.MagickFalse {
+		return 0, 0, 0, 0, fmt.Errorf(`Could not parse color "%s".`, value)
+	}
+
+	return float64(C.PixelGetRed(wand)), float64(C.PixelGetGreen(wand)), float64(C.PixelGetBlue(wand)), float64(C.PixelGetAlpha(wand)), nil
+}
+
+// Returns a linear gradient from (x1, y1) to (x2, y2), in canvas
+// coordinates. Colors are added with AddStop().
+func NewLinearGradient(x1 float64, y1 float64, x2 float64, y2 float64) *LinearGradient {
+	return &LinearGradient{X1: x1, Y1: y1, X2: x2, Y2: y2}
+}
+
+// Returns a radial gradient centered at (cx, cy) with the given radius, in
+// canvas coordinates. Colors are added with AddStop().
+func NewRadialGradient(cx float64, cy float64, radius float64) *RadialGradient {
+	return &RadialGradient{CX: cx, CY: cy, Radius: radius}
+}
+
+// Returns a pattern that tiles the current image of source. The source
+// canvas must outlive the pattern.
+func NewPattern(source *Canvas) *Pattern {
+	return &Pattern{source: source}
+}
+
+// Adds a color stop to the gradient. Offset ranges from 0 (start) to 1
+// (end), opacity from 0 (transparent) to 1 (opaque) and is applied on top of
+// the color's own alpha.
+func (self *gradient) AddStop(offset float64, color string, opacity float64) error {
+	r, g, b, a, err := parseColor(color)
+
+	if err != nil {
+		return err
+	}
+
+	a = a * math.Max(0, math.Min(1, opacity))
+
+	self.stops = append(self.stops, gradientStop{
+		offset: math.Max(0, math.Min(1, offset)),
+		r:      r * a,
+		g:      g * a,
+		b:      b * a,
+		a:      a,
+	})
+
+	sort.SliceStable(self.stops, func(i, j int) bool {
+		return self.stops[i].offset < self.stops[j].offset
+	})
+
+	return nil
+}
+
+// Private: returns the color of the gradient at t, between 0 and 1.
+func (self *gradient) colorAt(t float64) color.NRGBA64 {
+	var s gradientStop
+
+	switch {
+	case t <= self.stops[0].offset:
+		s = self.stops[0]
+	case t >= self.stops[len(self.stops)-1].offset:
+		s = self.stops[len(self.stops)-1]
+	default:
+		i := sort.Search(len(self.stops), func(i int) bool {
+			return self.stops[i].offset >= t
+		})
+		a, b := self.stops[i-1], self.stops[i]
+		f := (t - a.offset) / (b.offset - a.offset)
+		s = gradientStop{
+			r: a.r + (b.r-a.r)*f,
+			g: a.g + (b.g-a.g)*f,
+			b: a.b + (b.b-a.b)*f,
+			a: a.a + (b.a-a.a)*f,
+		}
+	}
+
+	if s.a == 0 {
+		return color.NRGBA64{}
+	}
+
+	return color.NRGBA64{
+		R: uint16(math.Min(1, s.r/s.a) * 0xffff),
+		G: uint16(math.Min(1, s.g/s.a) * 0xffff),
+		B: uint16(math.Min(1, s.b/s.a) * 0xffff),
+		A: uint16(s.a * 0xffff),
+	}
+}
+
+// Private: renders the gradient over bounds, position maps a pixel center
+// to a value between 0 and 1.
+func (self *gradient) render(bounds image.Rectangle, position func(x, y float64) float64) (*Canvas, error) {
+	if len(self.stops) == 0 {
+		return nil, errors.New("Could not render gradient: no color stops.")
+	}
+
+	img := image.NewNRGBA64(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetNRGBA64(x, y, self.colorAt(position(float64(x)+0.5, float64(y)+0.5)))
+		}
+	}
+
+	return FromImage(img)
+}
+
+// Private: draws the gradient over bounds of the drawing surface of c, where
+// it's rendered pixel by pixel.
+func (self *gradient) draw(c *Canvas, bounds image.Rectangle, position func(x, y float64) float64) error {
+	if bounds.Empty() {
+		return nil
+	}
+
+	rendered, err := self.render(bounds, position)
+
+	if err != nil {
+		return err
+	}
+
+	defer rendered.Destroy()
+
+	return c.drawCanvas(rendered, bounds.Min.X, bounds.Min.Y)
+}
+
+// Private: returns the part of polygon where the linear function f is
+// positive or zero.
+func clipPolygon(polygon []Point, f func(p Point) float64) []Point {
+	var clipped []Point
+
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		fp, fq := f(p), f(q)
+
+		if fp >= 0 {
+			clipped = append(clipped, p)
+		}
+
+		if (fp >= 0) != (fq >= 0) {
+			s := fp / (fp - fq)
+			clipped = append(clipped, Point{X: p.X + (q.X-p.X)*s, Y: p.Y + (q.Y-p.Y)*s})
+		}
+	}
+
+	return clipped
+}
+
+// Private: returns the pixels of a width x height area covered by points,
+// with a margin of a pixel for antialiased edges.
+func pointsBounds(points []Point, width uint, height uint) image.Rectangle {
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+
+	x0, y0, x1, y1 := points[0].X, points[0].Y, points[0].X, points[0].Y
+
+	for _, p := range points[1:] {
+		x0, y0 = math.Min(x0, p.X), math.Min(y0, p.Y)
+		x1, y1 = math.Max(x1, p.X), math.Max(y1, p.Y)
+	}
+
+	bounds := image.Rect(int(math.Floor(x0))-1, int(math.Floor(y0))-1, int(math.Ceil(x1))+1, int(math.Ceil(y1))+1)
+
+	return bounds.Intersect(image.Rect(0, 0, int(width), int(height)))
+}
+
+// Private: returns the corners of a width x height area.
+func areaPolygon(width uint, height uint) []Point {
+	return []Point{{0, 0}, {float64(width), 0}, {float64(width), float64(height)}, {0, float64(height)}}
+}
+
+// Private: maps a point to its position along the gradient line.
+func (self *LinearGradient) position(x, y float64) float64 {
+	dx, dy := self.X2-self.X1, self.Y2-self.Y1
+	length := dx*dx + dy*dy
+
+	if length == 0 {
+		return 0
+	}
+
+	return ((x-self.X1)*dx + (y-self.Y1)*dy) / length
+}
+
+func (self *LinearGradient) render(width uint, height uint) (*Canvas, error) {
+	return self.gradient.render(image.Rect(0, 0, int(width), int(height)), self.position)
+}
+
+// Only the band between the first and the last stops is rendered pixel by
+// pixel, the areas before and after it are filled with their colors.
+func (self *LinearGradient) draw(c *Canvas, width uint, height uint) error {
+	if len(self.stops) == 0 {
+		return errors.New("Could not render gradient: no color stops.")
+	}
+
+	area := areaPolygon(width, height)
+
+	if self.X1 == self.X2 && self.Y1 == self.Y2 {
+		c.fillPolygon(area, self.colorAt(0))
+		return nil
+	}
+
+	first, last := self.stops[0].offset, self.stops[len(self.stops)-1].offset
+
+	position := func(p Point) float64 {
+		return self.position(p.X, p.Y)
+	}
+
+	c.fillPolygon(clipPolygon(area, func(p Point) float64 { return first - position(p) }), self.colorAt(first))
+	c.fillPolygon(clipPolygon(area, func(p Point) float64 { return position(p) - last }), self.colorAt(last))
+
+	band := clipPolygon(area, func(p Point) float64 { return position(p) - first })
+	band = clipPolygon(band, func(p Point) float64 { return last - position(p) })
+
+	return self.gradient.draw(c, pointsBounds(band, width, height), self.position)
+}
+
+// Private: maps a point to its distance to the center, relative to the
+// radius.
+func (self *RadialGradient) position(x, y float64) float64 {
+	if self.Radius <= 0 {
+		return 1
+	}
+	return math.Hypot(x-self.CX, y-self.CY) / self.Radius
+}
+
+func (self *RadialGradient) render(width uint, height uint) (*Canvas, error) {
+	return self.gradient.render(image.Rect(0, 0, int(width), int(height)), self.position)
+}
+
+// Only the square around the circle is rendered pixel by pixel, the rest is
+// filled with the color of the last stop.
+func (self *RadialGradient) draw(c *Canvas, width uint, height uint) error {
+	if len(self.stops) == 0 {
+		return errors.New("Could not render gradient: no color stops.")
+	}
+
+	c.fillPolygon(areaPolygon(width, height), self.colorAt(1))
+
+	if self.Radius <= 0 {
+		return nil
+	}
+
+	corners := []Point{{self.CX - self.Radius, self.CY - self.Radius}, {self.CX + self.Radius, self.CY + self.Radius}}
+
+	return self.gradient.draw(c, pointsBounds(corners, width, height), self.position)
+}
+
+func (self *Pattern) render(width uint, height uint) (*Canvas, error) {
+	if self.source == nil || self.source.Frames() == 0 {
+		return nil, errors.New("Could not render pattern: source canvas is empty.")
+	}
+
+	tiled := New()
+
+	if err := tiled.Blank(width, height); err != nil {
+		tiled.Destroy()
+		return nil, err
+	}
+
+	wand := C.MagickTextureImage(tiled.wand, self.source.wand)
+
+	if wand == nil {
+		err := tiled.magickError("render pattern")
+		tiled.Destroy()
+		return nil, err
+	}
+
+	tiled.replaceWand(wand)
+
+	return tiled, nil
+}
+
+func (self *Pattern) draw(c *Canvas, width uint, height uint) error {
+	rendered, err := self.render(width, height)
+
+	if err != nil {
+		return err
+	}
+
+	defer rendered.Destroy()
+
+	return c.drawCanvas(rendered, 0, 0)
+}
+
+// Private: copies the current image of source to the drawing surface at
+// (x, y).
+func (self *Canvas) drawCanvas(source *Canvas, x int, y int) error {
+	status := C.DrawComposite(self.drawing, C.CopyCompositeOp, C.double(x), C.double(y), C.double(source.Width()), C.double(source.Height()), source.wand)
+
+	if status == C.MagickFalse {
+		return self.drawingError("draw canvas")
+	}
+
+	return nil
+}
+
+// Private: fills polygon with a solid color on the drawing surface.
+func (self *Canvas) fillPolygon(polygon []Point, c color.NRGBA64) {
+	if len(polygon) < 3 {
+		return
+	}
+
+	fill := C.NewPixelWand()
+	defer C.DestroyPixelWand(fill)
+
+	C.PixelSetRed(fill, C.double(float64(c.R)/0xffff))
+	C.PixelSetGreen(fill, C.double(float64(c.G)/0xffff))
+	C.PixelSetBlue(fill, C.double(float64(c.B)/0xffff))
+	C.PixelSetAlpha(fill, C.double(float64(c.A)/0xffff))
+
+	stroke := C.NewPixelWand()
+	defer C.DestroyPixelWand(stroke)
+
+	cnone := C.CString("none")
+	defer C.free(unsafe.Pointer(cnone))
+
+	C.PixelSetColor(stroke, cnone)
+
+	C.DrawSetFillColor(self.drawing, fill)
+	C.DrawSetFillOpacity(self.drawing, 1)
+	C.DrawSetStrokeColor(self.drawing, stroke)
+
+	self.Polygon(polygon)
+}
+
+// Private: defines paint as a pattern of the drawing surface that covers the
+// whole canvas and returns its URL.
+func (self *Canvas) definePaint(paint Paint) (string, error) {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return "", errors.New("Could not define paint: canvas is empty.")
+	}
+
+	self.patterns++
+	id := fmt.Sprintf("paint%d", self.patterns)
+
+	cid := C.CString(id)
+	defer C.free(unsafe.Pointer(cid))
+
+	// The colors set while drawing the paint don't leak out of the pattern.
+	C.DrawPushPattern(self.drawing, cid, 0, 0, C.double(width), C.double(height))
+	C.PushDrawingWand(self.drawing)
+	err := paint.draw(self, width, height)
+	C.PopDrawingWand(self.drawing)
+	C.DrawPopPattern(self.drawing)
+
+	if err != nil {
+		return "", err
+	}
+
+	return "#" + id, nil
+}
+
+// Fills enclosed areas on the current drawing surface with paint instead of
+// the fill color.
+func (self *Canvas) SetFillPaint(paint Paint) error {
+	url, err := self.definePaint(paint)
+
+	if err != nil {
+		return err
+	}
+
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(curl))
+
+	if C.DrawSetFillPatternURL(self.drawing, curl) == C.MagickFalse {
+		return self.drawingError("set fill paint")
+	}
+
+	return nil
+}
+
+// Strokes lines on the current drawing surface with paint instead of the
+// stroke color.
+func (self *Canvas) SetStrokePaint(paint Paint) error {
+	url, err := self.definePaint(paint)
+
+	if err != nil {
+		return err
+	}
+
+	curl := C.CString(url)
+	defer C.free(unsafe.Pointer(curl))
+
+	if C.DrawSetStrokePatternURL(self.drawing, curl) == C.MagickFalse {
+		return self.drawingError("set stroke paint")
+	}
+
+	return nil
+}
+
+// Creates a canvas of the given dimensions filled with paint.
+func (self *Canvas) BlankWithPaint(width uint, height uint, paint Paint) error {
+	if err := self.Blank(width, height); err != nil {
+		return err
+	}
+
+	rendered, err := paint.render(width, height)
+
+	if err != nil {
+		return err
+	}
+
+	defer rendered.Destroy()
+
+	if C.MagickCompositeImage(self.wand, rendered.wand, C.CopyCompositeOp, 0, 0) == C.MagickFalse {
+		return self.magickError("fill with paint")
+	}
+
+	return nil
+}
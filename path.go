@@ -0,0 +1,105 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+// A point on the drawing surface.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Private: converts points into a PointInfo array.
+func pointInfo(points []Point) []C.PointInfo {
+	cpoints := make([]C.PointInfo, len(points))
+
+	for i, p := range points {
+		cpoints[i].x = C.double(p.X)
+		cpoints[i].y = C.double(p.Y)
+	}
+
+	return cpoints
+}
+
+// Starts a new path on the current drawing surface. A path is made of
+// MoveTo(), LineTo(), CurveTo(), QuadTo(), ArcTo() and ClosePath() calls and
+// must be ended with PathFinish().
+func (self *Canvas) PathStart() {
+	C.DrawPathStart(self.drawing)
+}
+
+// Ends the current path, which is then filled and stroked using the current
+// settings.
+func (self *Canvas) PathFinish() {
+	C.DrawPathFinish(self.drawing)
+}
+
+// Starts a new sub-path at the specified coordinates.
+func (self *Canvas) MoveTo(x float64, y float64) {
+	C.DrawPathMoveToAbsolute(self.drawing, C.double(x), C.double(y))
+}
+
+// Draws a straight line from the current point to the specified coordinates.
+func (self *Canvas) LineTo(x float64, y float64) {
+	C.DrawPathLineToAbsolute(self.drawing, C.double(x), C.double(y))
+}
+
+// Draws a cubic Bézier curve from the current point to (x, y) using (x1, y1)
+// and (x2, y2) as control points.
+func (self *Canvas) CurveTo(x1 float64, y1 float64, x2 float64, y2 float64, x float64, y float64) {
+	C.DrawPathCurveToAbsolute(self.drawing, C.double(x1), C.double(y1), C.double(x2), C.double(y2), C.double(x), C.double(y))
+}
+
+// Draws a quadratic Bézier curve from the current point to (x, y) using
+// (x1, y1) as control point.
+func (self *Canvas) QuadTo(x1 float64, y1 float64, x float64, y float64) {
+	C.DrawPathCurveToQuadraticBezierAbsolute(self.drawing, C.double(x1), C.double(y1), C.double(x), C.double(y))
+}
+
+// Draws an elliptical arc from the current point to (x, y). The ellipse has
+// radii rx and ry and is rotated by rad radians; largeArc and sweep choose
+// which of the four possible arcs is drawn, as in SVG.
+func (self *Canvas) ArcTo(rx float64, ry float64, rad float64, largeArc bool, sweep bool, x float64, y float64) {
+	C.DrawPathEllipticArcAbsolute(self.drawing, C.double(rx), C.double(ry), C.double(RAD_TO_DEG*rad), magickBoolean(largeArc), magickBoolean(sweep), C.double(x), C.double(y))
+}
+
+// Closes the current sub-path with a straight line to its starting point.
+func (self *Canvas) ClosePath() {
+	C.DrawPathClose(self.drawing)
+}
+
+// Draws a closed polygon through the given points.
+func (self *Canvas) Polygon(points []Point) {
+	if len(points) == 0 {
+		return
+	}
+	cpoints := pointInfo(points)
+	C.DrawPolygon(self.drawing, C.size_t(len(cpoints)), &cpoints[0])
+}
+
+// Draws an open line through the given points.
+func (self *Canvas) Polyline(points []Point) {
+	if len(points) == 0 {
+		return
+	}
+	cpoints := pointInfo(points)
+	C.DrawPolyline(self.drawing, C.size_t(len(cpoints)), &cpoints[0])
+}
+
+// Draws a Bézier curve through the given points. The first and last points
+// are the ends of the curve, the rest are control points.
+func (self *Canvas) Bezier(points []Point) {
+	if len(points) == 0 {
+		return
+	}
+	cpoints := pointInfo(points)
+	C.DrawBezier(self.drawing, C.size_t(len(cpoints)), &cpoints[0])
+}
+
+// Draws a rectangle with rounded corners of radii rx and ry over the current
+// drawing surface.
+func (self *Canvas) RoundRectangle(x float64, y float64, rx float64, ry float64) {
+	C.DrawRoundRectangle(self.drawing, C.double(0), C.double(0), C.double(x), C.double(y), C.double(rx), C.double(ry))
+}
@@ -7,36 +7,167 @@ import "C"
 
 import (
 	"fmt"
+	"image/color"
 	"unsafe"
 )
 
+// A single pixel of an image, see PixelIterator. Normalized channel values
+// range from 0 to 1, quantum values from 0 to Canvas.QuantumRange(). Pixel
+// implements color.Color.
 type Pixel struct {
 	wand *C.PixelWand
+	// Pixels returned by a PixelIterator are owned by it.
+	borrowed bool
 }
 
+// Returns the normalized red value of the pixel.
 func (self *Pixel) Red() float64 {
 	return float64(C.PixelGetRed(self.wand))
 }
 
+// Returns the normalized green value of the pixel.
 func (self *Pixel) Green() float64 {
 	return float64(C.PixelGetGreen(self.wand))
 }
 
+// Returns the normalized blue value of the pixel.
 func (self *Pixel) Blue() float64 {
 	return float64(C.PixelGetBlue(self.wand))
 }
 
+// Returns the normalized alpha value of the pixel, 0 is transparent.
+func (self *Pixel) Alpha() float64 {
+	return float64(C.PixelGetAlpha(self.wand))
+}
+
+// Sets the normalized red value of the pixel.
+func (self *Pixel) SetRed(value float64) {
+	C.PixelSetRed(self.wand, C.double(value))
+}
+
+// Sets the normalized green value of the pixel.
+func (self *Pixel) SetGreen(value float64) {
+	C.PixelSetGreen(self.wand, C.double(value))
+}
+
+// Sets the normalized blue value of the pixel.
+func (self *Pixel) SetBlue(value float64) {
+	C.PixelSetBlue(self.wand, C.double(value))
+}
+
+// Sets the normalized alpha value of the pixel, 0 is transparent.
+func (self *Pixel) SetAlpha(value float64) {
+	C.PixelSetAlpha(self.wand, C.double(value))
+}
+
+// Sets the normalized red, green, blue and alpha values of the pixel.
+func (self *Pixel) SetRGBA(r float64, g float64, b float64, a float64) {
+	C.PixelSetRed(self.wand, C.double(r))
+	C.PixelSetGreen(self.wand, C.double(g))
+	C.PixelSetBlue(self.wand, C.double(b))
+	C.PixelSetAlpha(self.wand, C.double(a))
+}
+
+// Returns the normalized cyan value of the pixel, for CMYK images.
+func (self *Pixel) Cyan() float64 {
+	return float64(C.PixelGetCyan(self.wand))
+}
+
+// Returns the normalized magenta value of the pixel, for CMYK images.
+func (self *Pixel) Magenta() float64 {
+	return float64(C.PixelGetMagenta(self.wand))
+}
+
+// Returns the normalized yellow value of the pixel, for CMYK images.
+func (self *Pixel) Yellow() float64 {
+	return float64(C.PixelGetYellow(self.wand))
+}
+
+// Returns the normalized black value of the pixel, for CMYK images.
+func (self *Pixel) Black() float64 {
+	return float64(C.PixelGetBlack(self.wand))
+}
+
+// Sets the normalized cyan, magenta, yellow and black values of the pixel,
+// for CMYK images.
+func (self *Pixel) SetCMYK(c float64, m float64, y float64, k float64) {
+	C.PixelSetCyan(self.wand, C.double(c))
+	C.PixelSetMagenta(self.wand, C.double(m))
+	C.PixelSetYellow(self.wand, C.double(y))
+	C.PixelSetBlack(self.wand, C.double(k))
+}
+
+// Returns the normalized hue, saturation and lightness of the pixel.
+func (self *Pixel) HSL() (float64, float64, float64) {
+	var h, s, l C.double
+	C.PixelGetHSL(self.wand, &h, &s, &l)
+	return float64(h), float64(s), float64(l)
+}
+
+// Sets the color of the pixel from normalized hue, saturation and lightness
+// values. Alpha is not modified.
+func (self *Pixel) SetHSL(h float64, s float64, l float64) {
+	C.PixelSetHSL(self.wand, C.double(h), C.double(s), C.double(l))
+}
+
+// Returns the red, green, blue and alpha quantum values of the pixel.
+func (self *Pixel) Quantum() (r uint, g uint, b uint, a uint) {
+	r = uint(C.PixelGetRedQuantum(self.wand))
+	g = uint(C.PixelGetGreenQuantum(self.wand))
+	b = uint(C.PixelGetBlueQuantum(self.wand))
+	a = uint(C.PixelGetAlphaQuantum(self.wand))
+	return
+}
+
+// Sets the red, green, blue and alpha quantum values of the pixel.
+func (self *Pixel) SetQuantum(r uint, g uint, b uint, a uint) {
+	C.PixelSetRedQuantum(self.wand, C.Quantum(r))
+	C.PixelSetGreenQuantum(self.wand, C.Quantum(g))
+	C.PixelSetBlueQuantum(self.wand, C.Quantum(b))
+	C.PixelSetAlphaQuantum(self.wand, C.Quantum(a))
+}
+
+// Returns the alpha-premultiplied red, green, blue and alpha values of the
+// pixel, ranging from 0 to 0xffff, as required by color.Color.
+func (self *Pixel) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA64{
+		R: uint16(self.Red() * 0xffff),
+		G: uint16(self.Green() * 0xffff),
+		B: uint16(self.Blue() * 0xffff),
+		A: uint16(self.Alpha() * 0xffff),
+	}.RGBA()
+}
+
+// Sets the color of the pixel from a color.Color.
+func (self *Pixel) SetFromColor(c color.Color) {
+	n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	self.SetRGBA(float64(n.R)/0xffff, float64(n.G)/0xffff, float64(n.B)/0xffff, float64(n.A)/0xffff)
+}
+
+// Returns the color of the pixel in "#rrggbbaa" notation.
+func (self *Pixel) HexColor() string {
+	return colorString(self)
+}
+
+// Sets the color of the pixel from a color name (e.g. "#ff0000" or
+// "rgba(255,0,0,0.5)").
 func (self *Pixel) SetColor(color string) error {
 	ccolor := C.CString(color)
 	defer C.free(unsafe.Pointer(ccolor))
 
 	if C.PixelSetColor(self.wand, ccolor) == C.MagickFalse {
-		return fmt.Errorf("Could not set color")
+		return fmt.Errorf(`Could not set color "%s".`, color)
 	}
 
 	return nil
 }
 
+// Destroys the pixel. Pixels returned by a PixelIterator are destroyed along
+// with it, calling Destroy() on them does nothing.
 func (self *Pixel) Destroy() {
+	if self.borrowed || self.wand == nil {
+		return
+	}
 	C.DestroyPixelWand(self.wand)
+	self.wand = nil
 }
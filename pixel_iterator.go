@@ -10,51 +10,136 @@ static PixelWand* get_pixel_wands_at(PixelWand** pixel_wands, size_t position) {
 import "C"
 
 import (
-	"errors"
 	"fmt"
-	"unsafe"
 )
 
+// Iterates over the rows of a region of an image, see Canvas.PixelIterator().
+// Modified pixels are written back to the image by Sync().
 type PixelIterator struct {
 	iterator *C.PixelIterator
+	height   uint
+	// Wrappers of the current row, reused from row to row.
+	pixels []*Pixel
 }
 
-func (self *PixelIterator) NextRow() (row []*Pixel) {
-	pixelCount := C.size_t(0)
-	pixels := C.PixelGetNextIteratorRow(self.iterator, &pixelCount)
-
-	if pixels == nil {
+// Private: wraps a row of pixel wands owned by the iterator.
+func (self *PixelIterator) wrap(wands **C.PixelWand, count C.size_t) []*Pixel {
+	if wands == nil {
 		return nil
 	}
 
-	for i := 0; i < int(pixelCount); i++ {
-		wand := C.get_pixel_wands_at(pixels, C.size_t(i))
-		row = append(row, &Pixel{wand: wand})
+	n := int(count)
+
+	for len(self.pixels) < n {
+		self.pixels = append(self.pixels, &Pixel{borrowed: true})
+	}
+
+	row := self.pixels[:n]
+
+	for i := range row {
+		row[i].wand = C.get_pixel_wands_at(wands, C.size_t(i))
 	}
 
-	return
+	return row
 }
 
-func (self *PixelIterator) Sync() error {
-	if C.PixelSyncIterator(self.iterator) == C.MagickFalse {
-		return fmt.Errorf("cannot sync iterator: %s", self.Error())
+// Moves to the next row and returns its pixels, or nil after the last row.
+// The first call returns the first row. The returned pixels belong to the
+// iterator and are only valid until the next row is requested.
+func (self *PixelIterator) NextRow() []*Pixel {
+	var count C.size_t
+	return self.wrap(C.PixelGetNextIteratorRow(self.iterator, &count), count)
+}
+
+// Moves to the previous row and returns its pixels, or nil before the first
+// row.
+func (self *PixelIterator) PreviousRow() []*Pixel {
+	var count C.size_t
+	return self.wrap(C.PixelGetPreviousIteratorRow(self.iterator, &count), count)
+}
+
+// Returns the pixels of the current row.
+func (self *PixelIterator) CurrentRow() []*Pixel {
+	var count C.size_t
+	return self.wrap(C.PixelGetCurrentIteratorRow(self.iterator, &count), count)
+}
+
+// Returns the index of the current row, relative to the region.
+func (self *PixelIterator) Row() int {
+	return int(C.PixelGetIteratorRow(self.iterator))
+}
+
+// Returns the number of rows of the region.
+func (self *PixelIterator) Rows() uint {
+	return self.height
+}
+
+// Makes row the current row, CurrentRow() returns it and NextRow() the one
+// after it.
+func (self *PixelIterator) SetRow(row int) error {
+	if row < 0 || row >= int(self.height) {
+		return fmt.Errorf("Could not set iterator row: %d is out of range [0, %d).", row, self.height)
+	}
+
+	if C.PixelSetIteratorRow(self.iterator, C.ssize_t(row)) == C.MagickFalse {
+		return self.magickError("set iterator row")
 	}
 
 	return nil
 }
 
-func (self *PixelIterator) Error() error {
-	var exception C.ExceptionType
+// Rewinds the iterator, the next call to NextRow() returns the first row.
+func (self *PixelIterator) Reset() {
+	C.PixelResetIterator(self.iterator)
+}
+
+// Returns a function that iterates over the index and pixels of every row,
+// from the first one, for use with range:
+//
+//	for y, row := range iterator.All() {
+//		...
+//		iterator.Sync()
+//	}
+func (self *PixelIterator) All() func(yield func(int, []*Pixel) bool) {
+	return func(yield func(int, []*Pixel) bool) {
+		self.Reset()
 
-	ptr := C.PixelGetIteratorException(self.iterator, &exception)
-	message := C.GoString(ptr)
+		for row := self.NextRow(); row != nil; row = self.NextRow() {
+			if !yield(self.Row(), row) {
+				return
+			}
+		}
+	}
+}
+
+// Writes the pixels of the current row back to the image.
+func (self *PixelIterator) Sync() error {
+	if C.PixelSyncIterator(self.iterator) == C.MagickFalse {
+		return self.magickError("sync iterator")
+	}
 
-	C.PixelClearIteratorException(self.iterator)
-	C.MagickRelinquishMemory(unsafe.Pointer(ptr))
+	return nil
+}
 
-	return errors.New(message)
+// Returns the latest exception reported by the iterator as a *MagickError,
+// or nil if there is none.
+func (self *PixelIterator) Error() error {
+	if C.PixelGetIteratorExceptionType(self.iterator) == C.UndefinedException {
+		return nil
+	}
+	return self.magickError("")
 }
 
+// Destroys the iterator and invalidates the pixels it returned.
 func (self *PixelIterator) Destroy() {
-	C.DestroyPixelIterator(self.iterator)
+	for _, pixel := range self.pixels {
+		pixel.wand = nil
+	}
+
+	self.pixels = nil
+
+	if self.iterator != nil {
+		C.DestroyPixelIterator(self.iterator)
+		self.iterator = nil
+	}
 }
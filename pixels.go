@@ -0,0 +1,179 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Type of the values of a pixel buffer. Integer values range from 0 to their
+// maximum, floating point values from 0 to 1.
+type StorageType uint
+
+const (
+	// []uint8
+	CharStorage StorageType = StorageType(C.CharPixel)
+	// []uint16
+	ShortStorage = StorageType(C.ShortPixel)
+	// []float32
+	FloatStorage = StorageType(C.FloatPixel)
+	// []float64
+	DoubleStorage = StorageType(C.DoublePixel)
+)
+
+// Private: validates a region and a channel map and returns the number of
+// values it holds.
+func pixelsLength(width uint, height uint, channels string) (int, error) {
+	if width == 0 || height == 0 {
+		return 0, errors.New("region is empty")
+	}
+
+	if channels == "" {
+		return 0, errors.New("channel map is empty")
+	}
+
+	return int(width) * int(height) * len(channels), nil
+}
+
+// Private: values a pixel buffer may hold.
+type pixelValue interface {
+	uint8 | uint16 | float32 | float64
+}
+
+// Private: exports the pixels of a rectangle into a new []T of the given
+// storage.
+func exportPixelsAs[T pixelValue](self *Canvas, x int, y int, width uint, height uint, channels string, storage StorageType) ([]T, error) {
+	length, err := pixelsLength(width, height, channels)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not export pixels: %s.", err)
+	}
+
+	pixels := make([]T, length)
+
+	if err := self.exportPixels(x, y, width, height, channels, C.StorageType(storage), unsafe.Pointer(&pixels[0])); err != nil {
+		return nil, err
+	}
+
+	return pixels, nil
+}
+
+// Private: imports the pixels of a rectangle from a []T of the given storage.
+func importPixelsAs[T pixelValue](self *Canvas, x int, y int, width uint, height uint, channels string, storage StorageType, pixels []T) error {
+	length, err := pixelsLength(width, height, channels)
+
+	if err != nil {
+		return fmt.Errorf("Could not import pixels: %s.", err)
+	}
+
+	if len(pixels) < length {
+		return fmt.Errorf("Could not import pixels: got %d values, expecting %d.", len(pixels), length)
+	}
+
+	return self.importPixels(x, y, width, height, channels, C.StorageType(storage), unsafe.Pointer(&pixels[0]))
+}
+
+// Returns the pixels of the rectangle (x, y, width, height) of the current
+// image, row by row, as 8-bit values. channels is a map such as "RGBA",
+// "BGR" or "I" (see MagickExportImagePixels() for the full list of
+// channels).
+func (self *Canvas) ExportPixels8(x int, y int, width uint, height uint, channels string) ([]uint8, error) {
+	return exportPixelsAs[uint8](self, x, y, width, height, channels, CharStorage)
+}
+
+// Returns the pixels of a rectangle of the current image as 16-bit values,
+// see ExportPixels8().
+func (self *Canvas) ExportPixels16(x int, y int, width uint, height uint, channels string) ([]uint16, error) {
+	return exportPixelsAs[uint16](self, x, y, width, height, channels, ShortStorage)
+}
+
+// Returns the pixels of a rectangle of the current image as float32 values
+// ranging from 0 to 1, see ExportPixels8().
+func (self *Canvas) ExportPixelsFloat32(x int, y int, width uint, height uint, channels string) ([]float32, error) {
+	return exportPixelsAs[float32](self, x, y, width, height, channels, FloatStorage)
+}
+
+// Returns the pixels of a rectangle of the current image as float64 values
+// ranging from 0 to 1, see ExportPixels8().
+func (self *Canvas) ExportPixelsFloat64(x int, y int, width uint, height uint, channels string) ([]float64, error) {
+	return exportPixelsAs[float64](self, x, y, width, height, channels, DoubleStorage)
+}
+
+// Replaces the pixels of the rectangle (x, y, width, height) of the current
+// image with 8-bit values, row by row, holding a value for each channel of
+// channels (see ExportPixels8()).
+func (self *Canvas) ImportPixels8(x int, y int, width uint, height uint, channels string, pixels []uint8) error {
+	return importPixelsAs(self, x, y, width, height, channels, CharStorage, pixels)
+}
+
+// Replaces the pixels of a rectangle of the current image with 16-bit
+// values, see ImportPixels8().
+func (self *Canvas) ImportPixels16(x int, y int, width uint, height uint, channels string, pixels []uint16) error {
+	return importPixelsAs(self, x, y, width, height, channels, ShortStorage, pixels)
+}
+
+// Replaces the pixels of a rectangle of the current image with float32
+// values ranging from 0 to 1, see ImportPixels8().
+func (self *Canvas) ImportPixelsFloat32(x int, y int, width uint, height uint, channels string, pixels []float32) error {
+	return importPixelsAs(self, x, y, width, height, channels, FloatStorage, pixels)
+}
+
+// Replaces the pixels of a rectangle of the current image with float64
+// values ranging from 0 to 1, see ImportPixels8().
+func (self *Canvas) ImportPixelsFloat64(x int, y int, width uint, height uint, channels string, pixels []float64) error {
+	return importPixelsAs(self, x, y, width, height, channels, DoubleStorage, pixels)
+}
+
+// Returns the pixels of the rectangle (x, y, width, height) of the current
+// image, row by row. The returned value is a []uint8, []uint16, []float32
+// or []float64 depending on storage. Prefer the typed ExportPixels8(),
+// ExportPixels16(), ExportPixelsFloat32() and ExportPixelsFloat64(), whose
+// buffer type is checked at compile time.
+func (self *Canvas) ExportPixels(x int, y int, width uint, height uint, channels string, storage StorageType) (interface{}, error) {
+	var pixels interface{}
+	var err error
+
+	switch storage {
+	case CharStorage:
+		pixels, err = self.ExportPixels8(x, y, width, height, channels)
+	case ShortStorage:
+		pixels, err = self.ExportPixels16(x, y, width, height, channels)
+	case FloatStorage:
+		pixels, err = self.ExportPixelsFloat32(x, y, width, height, channels)
+	case DoubleStorage:
+		pixels, err = self.ExportPixelsFloat64(x, y, width, height, channels)
+	default:
+		return nil, fmt.Errorf("Could not export pixels: unknown storage type %d.", storage)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pixels, nil
+}
+
+// Replaces the pixels of the rectangle (x, y, width, height) of the current
+// image with pixels, row by row. pixels must be a []uint8, []uint16,
+// []float32 or []float64. Prefer the typed ImportPixels8(),
+// ImportPixels16(), ImportPixelsFloat32() and ImportPixelsFloat64(), whose
+// buffer type is checked at compile time.
+func (self *Canvas) ImportPixels(x int, y int, width uint, height uint, channels string, pixels interface{}) error {
+	switch buf := pixels.(type) {
+	case []uint8:
+		return self.ImportPixels8(x, y, width, height, channels, buf)
+	case []uint16:
+		return self.ImportPixels16(x, y, width, height, channels, buf)
+	case []float32:
+		return self.ImportPixelsFloat32(x, y, width, height, channels, buf)
+	case []float64:
+		return self.ImportPixelsFloat64(x, y, width, height, channels, buf)
+	}
+
+	return fmt.Errorf("Could not import pixels: unsupported buffer type %T.", pixels)
+}
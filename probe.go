@@ -0,0 +1,109 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+)
+
+// Holds the properties of an image that can be learned without decoding its
+// pixels.
+type ImageInfo struct {
+	Format      string
+	Width       uint
+	Height      uint
+	Frames      uint
+	Colorspace  Colorspace
+	Depth       uint
+	Orientation uint
+	HasAlpha    bool
+	FileSize    int64
+}
+
+// Private: returns the information of the first image in the wand. Only
+// header attributes are read, so it's safe to use on pinged images.
+func (self *Canvas) imageInfo() *ImageInfo {
+	C.MagickSetIteratorIndex(self.wand, 0)
+
+	image := C.GetImageFromMagickWand(self.wand)
+
+	return &ImageInfo{
+		Format:      C.GoString(&image.magick[0]),
+		Width:       uint(image.columns),
+		Height:      uint(image.rows),
+		Frames:      self.Frames(),
+		Colorspace:  Colorspace(image.colorspace),
+		Depth:       uint(image.depth),
+		Orientation: uint(image.orientation),
+		HasAlpha:    image.matte == C.MagickTrue,
+	}
+}
+
+// Reads the format, dimensions and other basic properties of an image file
+// without decoding its pixels. Useful to validate uploads before calling
+// Open().
+func Probe(filename string) (*ImageInfo, error) {
+	stat, err := os.Stat(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.IsDir() == true {
+		return nil, fmt.Errorf(`Could not probe file "%s": it's a directory!`, filename)
+	}
+
+	self := New()
+	defer self.Destroy()
+
+	cfilename := C.CString(filename)
+	status := C.MagickPingImage(self.wand, cfilename)
+	C.free(unsafe.Pointer(cfilename))
+
+	if status == C.MagickFalse {
+		return nil, self.magickError(fmt.Sprintf(`probe image "%s"`, filename))
+	}
+
+	info := self.imageInfo()
+	info.FileSize = stat.Size()
+
+	return info, nil
+}
+
+// Reads the format, dimensions and other basic properties of an image read
+// from r without decoding its pixels.
+func ProbeReader(r io.Reader) (*ImageInfo, error) {
+	buf := &bytes.Buffer{}
+
+	n, err := buf.ReadFrom(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n == 0 {
+		return nil, errors.New("Could not probe image from reader: no data.")
+	}
+
+	self := New()
+	defer self.Destroy()
+
+	blob := buf.Bytes()
+
+	if C.MagickPingImageBlob(self.wand, unsafe.Pointer(&blob[0]), C.size_t(len(blob))) == C.MagickFalse {
+		return nil, self.magickError("probe image from reader")
+	}
+
+	info := self.imageInfo()
+	info.FileSize = n
+
+	return info, nil
+}
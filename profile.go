@@ -0,0 +1,145 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// Returns the colorspace of the current image.
+func (self *Canvas) Colorspace() Colorspace {
+	return Colorspace(C.MagickGetImageColorspace(self.wand))
+}
+
+// Converts the pixels of every frame to the given colorspace. To convert
+// between colorspaces with ICC profiles instead, use ConvertToProfile().
+func (self *Canvas) TransformColorspace(colorspace Colorspace) error {
+	return self.eachImage(func() error {
+		if C.MagickTransformImageColorspace(self.wand, C.ColorspaceType(colorspace)) == C.MagickFalse {
+			return self.magickError("transform colorspace")
+		}
+		return nil
+	})
+}
+
+// Returns the names of the profiles embedded in the current image (e.g.
+// "icc", "exif", "iptc" or "xmp"), sorted.
+func (self *Canvas) Profiles() []string {
+	var n C.size_t
+
+	cpattern := C.CString("*")
+	defer C.free(unsafe.Pointer(cpattern))
+
+	cnames := C.MagickGetImageProfiles(self.wand, cpattern, &n)
+
+	if cnames == nil {
+		return nil
+	}
+
+	defer C.MagickRelinquishMemory(unsafe.Pointer(cnames))
+
+	names := make([]string, 0, int(n))
+
+	for _, cname := range unsafe.Slice(cnames, int(n)) {
+		names = append(names, C.GoString(cname))
+		C.MagickRelinquishMemory(unsafe.Pointer(cname))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Returns the raw data of the named profile of the current image, or nil if
+// the image doesn't have it.
+func (self *Canvas) Profile(name string) []byte {
+	var n C.size_t
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	data := C.MagickGetImageProfile(self.wand, cname, &n)
+
+	if data == nil {
+		return nil
+	}
+
+	defer C.MagickRelinquishMemory(unsafe.Pointer(data))
+
+	return C.GoBytes(unsafe.Pointer(data), C.int(n))
+}
+
+// Embeds data as the named profile of the current image, replacing any
+// profile with the same name. Pixels are not modified, use
+// ConvertToProfile() to convert them to an ICC profile.
+func (self *Canvas) SetProfile(name string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf(`Could not set profile "%s": profile is empty.`, name)
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	if C.MagickSetImageProfile(self.wand, cname, unsafe.Pointer(&data[0]), C.size_t(len(data))) == C.MagickFalse {
+		return self.magickError(fmt.Sprintf(`set profile "%s"`, name))
+	}
+
+	return nil
+}
+
+// Private: removes the named profile from the current image.
+func (self *Canvas) removeProfile(name string) {
+	var n C.size_t
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	data := C.MagickRemoveImageProfile(self.wand, cname, &n)
+
+	if data != nil {
+		C.MagickRelinquishMemory(unsafe.Pointer(data))
+	}
+}
+
+// Removes the named profile from every frame. Removing a profile that
+// doesn't exist is not an error.
+func (self *Canvas) RemoveProfile(name string) error {
+	return self.eachImage(func() error {
+		self.removeProfile(name)
+		return nil
+	})
+}
+
+// Converts the pixels of every frame from their embedded ICC profile to icc,
+// which is embedded in its place, using intent for out of gamut colors.
+// Images without an embedded profile can't be converted, icc is just
+// embedded; set the profile they were created with first with
+// SetProfile("icc", ...). E.g. converting a CMYK JPEG to sRGB:
+//
+//	canvas.ConvertToProfile(srgbICC, PERCEPTUAL_INTENT)
+func (self *Canvas) ConvertToProfile(icc []byte, intent RenderingIntent) error {
+	if len(icc) == 0 {
+		return errors.New("Could not convert to profile: profile is empty.")
+	}
+
+	cname := C.CString("icc")
+	defer C.free(unsafe.Pointer(cname))
+
+	return self.eachImage(func() error {
+		if C.MagickSetImageRenderingIntent(self.wand, C.RenderingIntent(intent)) == C.MagickFalse {
+			return self.magickError("set rendering intent")
+		}
+
+		if C.MagickProfileImage(self.wand, cname, unsafe.Pointer(&icc[0]), C.size_t(len(icc))) == C.MagickFalse {
+			return self.magickError("convert to profile")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,179 @@
+package canvas
+
+/*
+#include <wand/MagickWand.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+)
+
+// Statistics of a single channel. Min, Max, Mean and StandardDeviation range
+// from 0 to 1. Entropy is the Shannon entropy of the channel's 8-bit
+// histogram, from 0 (a single value) to 1 (all 256 values equally frequent).
+type ChannelStatistics struct {
+	Depth             uint
+	Min               float64
+	Max               float64
+	Mean              float64
+	StandardDeviation float64
+	Kurtosis          float64
+	Skewness          float64
+	Entropy           float64
+}
+
+// Statistics of every channel of an image. Gray images report the same
+// values for Red, Green and Blue, Black is only set for CMYK images and
+// Alpha is opaque for images without alpha channel. Composite holds the
+// statistics of all the color channels together.
+type Statistics struct {
+	Red       ChannelStatistics
+	Green     ChannelStatistics
+	Blue      ChannelStatistics
+	Alpha     ChannelStatistics
+	Black     ChannelStatistics
+	Composite ChannelStatistics
+}
+
+// Private: returns the normalized statistics of a channel.
+func channelStatistics(s *C.ChannelStatistics, quantumRange float64) ChannelStatistics {
+	return ChannelStatistics{
+		Depth:             uint(s.depth),
+		Min:               float64(s.minima) / quantumRange,
+		Max:               float64(s.maxima) / quantumRange,
+		Mean:              float64(s.mean) / quantumRange,
+		StandardDeviation: float64(s.standard_deviation) / quantumRange,
+		Kurtosis:          float64(s.kurtosis),
+		Skewness:          float64(s.skewness),
+	}
+}
+
+// Private: returns the normalized entropy of an histogram with total values.
+func entropy(histogram []uint, total int) float64 {
+	var e float64
+
+	for _, n := range histogram {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(total)
+		e -= p * math.Log2(p)
+	}
+
+	return e / math.Log2(float64(len(histogram)))
+}
+
+// Returns the statistics of every channel of the current image.
+func (self *Canvas) Statistics() (*Statistics, error) {
+	width, height := self.Width(), self.Height()
+
+	if width == 0 || height == 0 {
+		return nil, errors.New("Could not compute statistics: canvas is empty.")
+	}
+
+	cstats := C.MagickGetImageChannelStatistics(self.wand)
+
+	if cstats == nil {
+		return nil, self.magickError("compute statistics")
+	}
+
+	defer C.MagickRelinquishMemory(unsafe.Pointer(cstats))
+
+	// The array is indexed by channel flags.
+	channels := unsafe.Slice(cstats, int(C.CompositeChannels)+1)
+
+	quantumRange := float64(self.QuantumRange())
+
+	stats := &Statistics{
+		Red:       channelStatistics(&channels[C.RedChannel], quantumRange),
+		Green:     channelStatistics(&channels[C.GreenChannel], quantumRange),
+		Blue:      channelStatistics(&channels[C.BlueChannel], quantumRange),
+		Alpha:     channelStatistics(&channels[C.OpacityChannel], quantumRange),
+		Composite: channelStatistics(&channels[C.CompositeChannels], quantumRange),
+	}
+
+	if C.MagickGetImageColorspace(self.wand) == C.CMYKColorspace {
+		stats.Black = channelStatistics(&channels[C.BlackChannel], quantumRange)
+	}
+
+	// ImageMagick measures opacity, the opposite of alpha.
+	if C.MagickGetImageAlphaChannel(self.wand) == C.MagickTrue {
+		a := &stats.Alpha
+		a.Min, a.Max = 1-a.Max, 1-a.Min
+		a.Mean = 1 - a.Mean
+		a.Skewness = -a.Skewness
+	} else {
+		stats.Alpha = ChannelStatistics{Depth: stats.Composite.Depth, Min: 1, Max: 1, Mean: 1}
+	}
+
+	// Entropy is computed from the 8-bit values of each channel, row by row,
+	// so memory doesn't grow with the image.
+	histograms := make([][]uint, 4)
+
+	for i := range histograms {
+		histograms[i] = make([]uint, 256)
+	}
+
+	pix := make([]uint8, 4*width)
+
+	for y := 0; y < int(height); y++ {
+		if err := self.exportPixels(0, y, width, 1, "RGBA", C.CharPixel, unsafe.Pointer(&pix[0])); err != nil {
+			return nil, err
+		}
+
+		for i, v := range pix {
+			histograms[i%4][v]++
+		}
+	}
+
+	total := int(width * height)
+
+	stats.Red.Entropy = entropy(histograms[0], total)
+	stats.Green.Entropy = entropy(histograms[1], total)
+	stats.Blue.Entropy = entropy(histograms[2], total)
+	stats.Alpha.Entropy = entropy(histograms[3], total)
+	stats.Composite.Entropy = (stats.Red.Entropy + stats.Green.Entropy + stats.Blue.Entropy) / 3
+
+	return stats, nil
+}
+
+// Returns the minimum and maximum values of all the channels of the current
+// image, ranging from 0 to 1.
+func (self *Canvas) Range() (float64, float64, error) {
+	var min, max C.double
+
+	if C.MagickGetImageRange(self.wand, &min, &max) == C.MagickFalse {
+		return 0, 0, self.magickError("compute range")
+	}
+
+	quantumRange := float64(self.QuantumRange())
+
+	return float64(min) / quantumRange, float64(max) / quantumRange, nil
+}
+
+// Returns true if every pixel of the current image is fully opaque.
+func (self *Canvas) IsOpaque() (bool, error) {
+	if self.Frames() == 0 {
+		return false, errors.New("Could not check opacity: canvas is empty.")
+	}
+
+	exception := C.AcquireExceptionInfo()
+	defer C.DestroyExceptionInfo(exception)
+
+	return C.IsOpaqueImage(C.GetImageFromMagickWand(self.wand), exception) == C.MagickTrue, nil
+}
+
+// Returns true if every pixel of the current image is a shade of gray.
+func (self *Canvas) IsGrayscale() (bool, error) {
+	if self.Frames() == 0 {
+		return false, errors.New("Could not check grayscale: canvas is empty.")
+	}
+
+	exception := C.AcquireExceptionInfo()
+	defer C.DestroyExceptionInfo(exception)
+
+	return C.IsGrayImage(C.GetImageFromMagickWand(self.wand), exception) == C.MagickTrue, nil
+}
@@ -1,15 +1,4 @@
-/* text.go contains functions for text annotation
-TODO:
-getters and setters for
-- Stretch (redefine C type)
-- Style (redefine C type)
-- Resolution (two doubles)
-- Decoration (redefine C type)
-- Encoding (string)
--  DrawSetTextInterlineSpacing(DrawingWand *,const double),
--  DrawSetTextInterwordSpacing(DrawingWand *,const double),
--  DrawSetGravity(DrawingWand *wand,const GravityType gravity)
-*/
+/* text.go contains functions for text annotation */
 
 package canvas
 
@@ -22,6 +11,9 @@ package canvas
 import "C"
 
 import (
+	"fmt"
+	"image/color"
+	"strings"
 	"unsafe"
 )
 
@@ -34,24 +26,93 @@ const (
 	RightAlign               = Alignment(C.RightAlign)
 )
 
+type Stretch uint
+
+const (
+	UndefinedStretch      Stretch = Stretch(C.UndefinedStretch)
+	NormalStretch                 = Stretch(C.NormalStretch)
+	UltraCondensedStretch         = Stretch(C.UltraCondensedStretch)
+	ExtraCondensedStretch         = Stretch(C.ExtraCondensedStretch)
+	CondensedStretch              = Stretch(C.CondensedStretch)
+	SemiCondensedStretch          = Stretch(C.SemiCondensedStretch)
+	SemiExpandedStretch           = Stretch(C.SemiExpandedStretch)
+	ExpandedStretch               = Stretch(C.ExpandedStretch)
+	ExtraExpandedStretch          = Stretch(C.ExtraExpandedStretch)
+	UltraExpandedStretch          = Stretch(C.UltraExpandedStretch)
+	AnyStretch                    = Stretch(C.AnyStretch)
+)
+
+type Style uint
+
+const (
+	UndefinedStyle Style = Style(C.UndefinedStyle)
+	NormalStyle          = Style(C.NormalStyle)
+	ItalicStyle          = Style(C.ItalicStyle)
+	ObliqueStyle         = Style(C.ObliqueStyle)
+	AnyStyle             = Style(C.AnyStyle)
+)
+
+type Decoration uint
+
+const (
+	UndefinedDecoration   Decoration = Decoration(C.UndefinedDecoration)
+	NoDecoration                     = Decoration(C.NoDecoration)
+	UnderlineDecoration              = Decoration(C.UnderlineDecoration)
+	OverlineDecoration               = Decoration(C.OverlineDecoration)
+	LineThroughDecoration            = Decoration(C.LineThroughDecoration)
+)
+
 // structure containing all text properties for an annotation
 // except the colors that are defined by FillColor and StrokeColor
 type TextProperties struct {
-	Font   string
-	Family string
-	Size   float64
-	// Stretch		C.StretchType
-	Weight uint
-	// Style		C.StyleType
-	// Resolution  [2]C.double
-	Alignment Alignment
-	Antialias bool
-	// Decoration	C.DecorationType
-	// Encoding	string
-	Kerning float64
-	// Interline	float64
-	// Interword	float64
-	UnderColor *C.PixelWand
+	Font    string
+	Family  string
+	Size    float64
+	Stretch Stretch
+	Weight  uint
+	Style   Style
+	// Horizontal and vertical resolution, in DPI
+	Resolution [2]float64
+	Alignment  Alignment
+	Antialias  bool
+	Decoration Decoration
+	Encoding   string
+	Kerning    float64
+	Interline  float64
+	Interword  float64
+	// See the *_GRAVITY constants
+	Gravity uint
+	// Color of the box drawn behind the text (e.g. "#ffff00"), none if empty
+	UnderColor string
+	// Space between the text and the edges of the under color box
+	UnderColorPadding float64
+	// Corner radius of the under color box
+	UnderColorRadius float64
+}
+
+// Sets the under color from a color.Color.
+func (self *TextProperties) SetUnderColor(c color.Color) {
+	self.UnderColor = colorString(c)
+}
+
+// Private: returns the "#rrggbbaa" notation of c.
+func colorString(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// Private: returns the "#rrggbbaa" notation of a pixel wand's color, or an
+// empty string if the color is fully transparent.
+func pixelColorString(p *C.PixelWand) string {
+	if C.PixelGetAlphaQuantum(p) == 0 {
+		return ""
+	}
+	return colorString(color.NRGBA64{
+		R: uint16(C.PixelGetRed(p) * 0xffff),
+		G: uint16(C.PixelGetGreen(p) * 0xffff),
+		B: uint16(C.PixelGetBlue(p) * 0xffff),
+		A: uint16(C.PixelGetAlpha(p) * 0xffff),
+	})
 }
 
 // Returns a TextProperties structure.
@@ -70,36 +131,132 @@ func (self *Canvas) NewTextProperties(readDefault bool) *TextProperties {
 		cantialias := C.DrawGetTextAntialias(self.drawing)
 		ckerning := C.DrawGetTextKerning(self.drawing)
 		antialias := cantialias == C.MagickTrue
+		cencoding := C.DrawGetTextEncoding(self.drawing)
+		defer C.free(unsafe.Pointer(cencoding))
+		var resolution [2]C.double
+		C.DrawGetFontResolution(self.drawing, &resolution[0], &resolution[1])
 
 		underColor := C.NewPixelWand()
+		defer C.DestroyPixelWand(underColor)
 		C.DrawGetTextUnderColor(self.drawing, underColor)
-		return &TextProperties{
+		prop := &TextProperties{
 			Font:       C.GoString(cfont),
 			Family:     C.GoString(cfamily),
 			Size:       float64(csize),
+			Stretch:    Stretch(C.DrawGetFontStretch(self.drawing)),
 			Weight:     uint(cweight),
+			Style:      Style(C.DrawGetFontStyle(self.drawing)),
+			Resolution: [2]float64{float64(resolution[0]), float64(resolution[1])},
 			Alignment:  Alignment(calignment),
 			Antialias:  antialias,
+			Decoration: Decoration(C.DrawGetTextDecoration(self.drawing)),
+			Encoding:   C.GoString(cencoding),
 			Kerning:    float64(ckerning),
-			UnderColor: underColor,
+			Interline:  float64(C.DrawGetTextInterlineSpacing(self.drawing)),
+			Interword:  float64(C.DrawGetTextInterwordSpacing(self.drawing)),
+			Gravity:    uint(C.DrawGetGravity(self.drawing)),
+			UnderColor: pixelColorString(underColor),
 		}
+		// Padding and radius are not part of the drawing surface.
+		if self.text != nil {
+			prop.UnderColorPadding = self.text.UnderColorPadding
+			prop.UnderColorRadius = self.text.UnderColorRadius
+			if self.customUnderColor() {
+				prop.UnderColor = self.text.UnderColor
+			}
+		}
+		return prop
 	}
-	return &TextProperties{
-		UnderColor: C.NewPixelWand(),
-	}
+	return &TextProperties{}
 }
 
-// Sets canvas' default TextProperties
+// Sets canvas' default TextProperties. Stretch, Style, Decoration,
+// Resolution and Encoding keep their current value when left undefined in
+// def. Nothing is changed if def.UnderColor is not a valid color, the
+// failure is then reported by Error().
 func (self *Canvas) SetTextProperties(def *TextProperties) {
+	if err := self.setTextProperties(def); err != nil {
+		self.err = err
+	}
+}
+
+// Private: sets canvas' default TextProperties, see SetTextProperties().
+func (self *Canvas) setTextProperties(def *TextProperties) error {
 	if def != nil {
-		self.text = def
+		if err := checkUnderColor(def.UnderColor); err != nil {
+			return err
+		}
+		text := *def
+		if self.text != nil {
+			if def.Stretch == UndefinedStretch {
+				text.Stretch = self.text.Stretch
+			}
+			if def.Style == UndefinedStyle {
+				text.Style = self.text.Style
+			}
+			if def.Decoration == UndefinedDecoration {
+				text.Decoration = self.text.Decoration
+			}
+			if def.Resolution[0] <= 0 || def.Resolution[1] <= 0 {
+				text.Resolution = self.text.Resolution
+			}
+			if len(def.Encoding) == 0 {
+				text.Encoding = self.text.Encoding
+			}
+		}
+		self.text = &text
 		self.SetFontFamily(def.Family)
 		self.SetFont(def.Font, def.Size)
 		self.SetFontWeight(def.Weight)
 		self.SetTextAlignment(def.Alignment)
 		self.SetTextAntialias(def.Antialias)
 		self.SetTextKerning(def.Kerning)
+		self.SetTextInterlineSpacing(def.Interline)
+		self.SetTextInterwordSpacing(def.Interword)
+		self.SetTextGravity(def.Gravity)
+		if def.Stretch != UndefinedStretch {
+			self.SetFontStretch(def.Stretch)
+		}
+		if def.Style != UndefinedStyle {
+			self.SetFontStyle(def.Style)
+		}
+		if def.Decoration != UndefinedDecoration {
+			self.SetTextDecoration(def.Decoration)
+		}
+		if def.Resolution[0] > 0 && def.Resolution[1] > 0 {
+			if err := self.SetFontResolution(def.Resolution[0], def.Resolution[1]); err != nil {
+				return err
+			}
+		}
+		if len(def.Encoding) > 0 {
+			self.SetTextEncoding(def.Encoding)
+		}
+		if err := self.SetTextUnderColor(def.UnderColor); err != nil {
+			return err
+		}
+		self.SetTextUnderColorBox(def.UnderColorPadding, def.UnderColorRadius)
+	}
+	return nil
+}
+
+// Private: makes prop the canvas' TextProperties until the returned function
+// is called. The drawing surface is saved and restored as a whole, so
+// settings that SetTextProperties() skips for prop don't leak either.
+func (self *Canvas) pushTextProperties(prop *TextProperties) (func(), error) {
+	tmp := self.text
+	C.PushDrawingWand(self.drawing)
+
+	restore := func() {
+		C.PopDrawingWand(self.drawing)
+		self.text = tmp
+	}
+
+	if err := self.setTextProperties(prop); err != nil {
+		restore()
+		return nil, err
 	}
+
+	return restore, nil
 }
 
 // Gets a copy of canvas' current TextProperties
@@ -210,19 +367,382 @@ func (self *Canvas) TextKerning() float64 {
 	return self.text.Kerning
 }
 
+// Sets canvas' default font stretch
+func (self *Canvas) SetFontStretch(stretch Stretch) {
+	self.text.Stretch = stretch
+	C.DrawSetFontStretch(self.drawing, C.StretchType(stretch))
+}
+
+// Returns canvas' current font stretch
+func (self *Canvas) FontStretch() Stretch {
+	return self.text.Stretch
+}
+
+// Sets canvas' default font style. Available values are:
+// NormalStyle, ItalicStyle, ObliqueStyle, AnyStyle
+func (self *Canvas) SetFontStyle(style Style) {
+	self.text.Style = style
+	C.DrawSetFontStyle(self.drawing, C.StyleType(style))
+}
+
+// Returns canvas' current font style
+func (self *Canvas) FontStyle() Style {
+	return self.text.Style
+}
+
+// Sets canvas' default font resolution, in DPI
+func (self *Canvas) SetFontResolution(x float64, y float64) error {
+	if C.DrawSetFontResolution(self.drawing, C.double(x), C.double(y)) == C.MagickFalse {
+		return self.drawingError("set font resolution")
+	}
+	self.text.Resolution = [2]float64{x, y}
+	return nil
+}
+
+// Returns canvas' current horizontal and vertical font resolution
+func (self *Canvas) FontResolution() (float64, float64) {
+	return self.text.Resolution[0], self.text.Resolution[1]
+}
+
+// Sets canvas' default text decoration. Available values are:
+// NoDecoration, UnderlineDecoration, OverlineDecoration, LineThroughDecoration
+func (self *Canvas) SetTextDecoration(decoration Decoration) {
+	self.text.Decoration = decoration
+	C.DrawSetTextDecoration(self.drawing, C.DecorationType(decoration))
+}
+
+// Returns canvas' current text decoration
+func (self *Canvas) TextDecoration() Decoration {
+	return self.text.Decoration
+}
+
+// Sets canvas' default text encoding (e.g. "UTF-8")
+func (self *Canvas) SetTextEncoding(encoding string) {
+	self.text.Encoding = encoding
+	cencoding := C.CString(encoding)
+	defer C.free(unsafe.Pointer(cencoding))
+	C.DrawSetTextEncoding(self.drawing, cencoding)
+}
+
+// Returns canvas' current text encoding
+func (self *Canvas) TextEncoding() string {
+	return self.text.Encoding
+}
+
+// Sets canvas' default spacing between lines, in pixels
+func (self *Canvas) SetTextInterlineSpacing(spacing float64) {
+	self.text.Interline = spacing
+	C.DrawSetTextInterlineSpacing(self.drawing, C.double(spacing))
+}
+
+// Returns canvas' current spacing between lines
+func (self *Canvas) TextInterlineSpacing() float64 {
+	return self.text.Interline
+}
+
+// Sets canvas' default spacing between words, in pixels
+func (self *Canvas) SetTextInterwordSpacing(spacing float64) {
+	self.text.Interword = spacing
+	C.DrawSetTextInterwordSpacing(self.drawing, C.double(spacing))
+}
+
+// Returns canvas' current spacing between words
+func (self *Canvas) TextInterwordSpacing() float64 {
+	return self.text.Interword
+}
+
+// Sets canvas' default text gravity, see the *_GRAVITY constants. With a
+// gravity other than UNDEFINED_GRAVITY, Annotate() coordinates are offsets
+// from the given side or corner of the canvas.
+func (self *Canvas) SetTextGravity(gravity uint) {
+	self.text.Gravity = gravity
+	C.DrawSetGravity(self.drawing, C.GravityType(gravity))
+}
+
+// Returns canvas' current text gravity
+func (self *Canvas) TextGravity() uint {
+	return self.text.Gravity
+}
+
+// Private: returns true if the under color box is drawn by Annotate() instead
+// of ImageMagick, which doesn't support padding nor rounded corners.
+func (self *Canvas) customUnderColor() bool {
+	return self.text.UnderColor != "" && (self.text.UnderColorPadding > 0 || self.text.UnderColorRadius > 0)
+}
+
+// Private: returns an error if color is neither empty nor a valid color.
+func checkUnderColor(color string) error {
+	if color == "" {
+		return nil
+	}
+
+	ccolor := C.CString(color)
+	defer C.free(unsafe.Pointer(ccolor))
+
+	underColor := C.NewPixelWand()
+	defer C.DestroyPixelWand(underColor)
+
+	if C.PixelSetColor(underColor, ccolor) == C.MagickFalse {
+		return fmt.Errorf(`Could not set text under color "%s".`, color)
+	}
+
+	return nil
+}
+
+// Private: applies the under color to the drawing surface.
+func (self *Canvas) applyUnderColor() error {
+	color := self.text.UnderColor
+	if color == "" || self.customUnderColor() {
+		color = "none"
+	}
+
+	ccolor := C.CString(color)
+	defer C.free(unsafe.Pointer(ccolor))
+
+	underColor := C.NewPixelWand()
+	defer C.DestroyPixelWand(underColor)
+
+	if C.PixelSetColor(underColor, ccolor) == C.MagickFalse {
+		return fmt.Errorf(`Could not set text under color "%s".`, color)
+	}
+
+	C.DrawSetTextUnderColor(self.drawing, underColor)
+
+	return nil
+}
+
+// Sets canvas' default text under color, the color of the box drawn behind
+// the text. An empty string removes it.
+func (self *Canvas) SetTextUnderColor(color string) error {
+	prev := self.text.UnderColor
+	self.text.UnderColor = color
+
+	if err := self.applyUnderColor(); err != nil {
+		self.text.UnderColor = prev
+		return err
+	}
+
+	return nil
+}
+
+// Returns canvas' current text under color
+func (self *Canvas) TextUnderColor() string {
+	return self.text.UnderColor
+}
+
+// Sets the padding and corner radius of canvas' default under color box
+func (self *Canvas) SetTextUnderColorBox(padding float64, radius float64) {
+	self.text.UnderColorPadding = padding
+	self.text.UnderColorRadius = radius
+	self.applyUnderColor()
+}
+
+// Returns the padding and corner radius of canvas' current under color box
+func (self *Canvas) TextUnderColorBox() (float64, float64) {
+	return self.text.UnderColorPadding, self.text.UnderColorRadius
+}
+
+// Private: draws the under color box of text as placed by Annotate().
+func (self *Canvas) drawUnderColorBox(text string, x, y float64) error {
+	metrics, err := self.queryFontMetrics(text, true)
+
+	if err != nil {
+		return err
+	}
+
+	var left, top float64
+
+	switch self.text.Gravity {
+	case UNDEFINED_GRAVITY:
+		left, top = x, y-metrics.Ascent
+
+		switch self.text.Alignment {
+		case CenterAlign:
+			left -= metrics.Width / 2
+		case RightAlign:
+			left -= metrics.Width
+		}
+	default:
+		l, t := gravityOffset(self.text.Gravity, self.Width(), self.Height(), uint(metrics.Width), uint(metrics.Height), int(x), int(y))
+		left, top = float64(l), float64(t)
+	}
+
+	cfill := C.CString(self.text.UnderColor)
+	defer C.free(unsafe.Pointer(cfill))
+	cnone := C.CString("none")
+	defer C.free(unsafe.Pointer(cnone))
+
+	fill := C.NewPixelWand()
+	defer C.DestroyPixelWand(fill)
+	stroke := C.NewPixelWand()
+	defer C.DestroyPixelWand(stroke)
+
+	if C.PixelSetColor(fill, cfill) == C.MagickFalse {
+		return fmt.Errorf(`Could not set text under color "%s".`, self.text.UnderColor)
+	}
+	C.PixelSetColor(stroke, cnone)
+
+	p, r := self.text.UnderColorPadding, self.text.UnderColorRadius
+
+	C.PushDrawingWand(self.drawing)
+	C.DrawSetFillColor(self.drawing, fill)
+	C.DrawSetStrokeColor(self.drawing, stroke)
+	C.DrawRoundRectangle(self.drawing, C.double(left-p), C.double(top-p), C.double(left+metrics.Width+p), C.double(top+metrics.Height+p), C.double(r), C.double(r))
+	C.PopDrawingWand(self.drawing)
+
+	return nil
+}
+
 // Draws a string at the specified coordinates and using the current canvas
-// Alignment.
+// Alignment. Failures to draw the under color box are reported by Error().
 func (self *Canvas) Annotate(text string, x, y float64) {
+	if err := self.annotate(text, x, y); err != nil {
+		self.err = err
+	}
+}
+
+// Private: draws a string at the specified coordinates, see Annotate().
+func (self *Canvas) annotate(text string, x, y float64) error {
+	if self.customUnderColor() {
+		if err := self.drawUnderColorBox(text, x, y); err != nil {
+			return err
+		}
+	}
 	c_text := C.CString(text)
 	defer C.free(unsafe.Pointer(c_text))
 	C.DrawAnnotation(self.drawing, C.double(x), C.double(y), (*C.uchar)(unsafe.Pointer(c_text)))
+	return nil
 }
 
 // Draws a string at the specified coordinates and using the specified Text Properties
-// Does not modify the canvas' default TextProperties
+// Does not modify the canvas' default TextProperties. Nothing is drawn if
+// prop.UnderColor is not a valid color, the failure is then reported by
+// Error().
 func (self *Canvas) AnnotateWithProperties(text string, x, y float64, prop *TextProperties) {
-	tmp := self.TextProperties()
-	self.SetTextProperties(prop)
+	restore, err := self.pushTextProperties(prop)
+	if err != nil {
+		self.err = err
+		return
+	}
+	defer restore()
 	self.Annotate(text, x, y)
-	self.SetTextProperties(tmp)
+}
+
+// Metrics of a text rendered with a given set of TextProperties, in pixels.
+// Descent is negative when glyphs go below the baseline. The bounding box is
+// relative to the origin of the text.
+type FontMetrics struct {
+	CharacterWidth       float64
+	CharacterHeight      float64
+	Ascent               float64
+	Descent              float64
+	Width                float64
+	Height               float64
+	MaxHorizontalAdvance float64
+	X1                   float64
+	Y1                   float64
+	X2                   float64
+	Y2                   float64
+}
+
+// Metrics of a possibly multi-line text. The embedded FontMetrics hold the
+// overall metrics, Lines the metrics of every line.
+type TextMetrics struct {
+	FontMetrics
+	Lines []FontMetrics
+}
+
+// Private: queries the metrics of text using the current drawing settings.
+func (self *Canvas) queryFontMetrics(text string, multiline bool) (FontMetrics, error) {
+	target := self
+
+	// MagickWand needs an image to compute metrics.
+	if target.Frames() == 0 {
+		target = New()
+		defer target.Destroy()
+
+		if err := target.Blank(1, 1); err != nil {
+			return FontMetrics{}, err
+		}
+	}
+
+	ctext := C.CString(text)
+	defer C.free(unsafe.Pointer(ctext))
+
+	var cmetrics *C.double
+
+	if multiline {
+		cmetrics = C.MagickQueryMultilineFontMetrics(target.wand, self.drawing, ctext)
+	} else {
+		cmetrics = C.MagickQueryFontMetrics(target.wand, self.drawing, ctext)
+	}
+
+	if cmetrics == nil {
+		return FontMetrics{}, target.magickError("measure text")
+	}
+
+	defer C.MagickRelinquishMemory(unsafe.Pointer(cmetrics))
+
+	values := (*[13]C.double)(unsafe.Pointer(cmetrics))
+
+	return FontMetrics{
+		CharacterWidth:       float64(values[0]),
+		CharacterHeight:      float64(values[1]),
+		Ascent:               float64(values[2]),
+		Descent:              float64(values[3]),
+		Width:                float64(values[4]),
+		Height:               float64(values[5]),
+		MaxHorizontalAdvance: float64(values[6]),
+		X1:                   float64(values[7]),
+		Y1:                   float64(values[8]),
+		X2:                   float64(values[9]),
+		Y2:                   float64(values[10]),
+	}, nil
+}
+
+// Returns the metrics of text as it would be drawn by Annotate() using the
+// specified Text Properties, or the canvas' current ones if prop is nil.
+// Lines are separated by "\n". Does not modify the canvas' default
+// TextProperties.
+func (self *Canvas) MeasureText(text string, prop *TextProperties) (TextMetrics, error) {
+	var metrics TextMetrics
+	var err error
+
+	if prop != nil {
+		restore, err := self.pushTextProperties(prop)
+		if err != nil {
+			return metrics, err
+		}
+		defer restore()
+	}
+
+	if text == "" {
+		return metrics, nil
+	}
+
+	metrics.FontMetrics, err = self.queryFontMetrics(text, true)
+
+	if err != nil {
+		return metrics, err
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		var lineMetrics FontMetrics
+
+		if line == "" {
+			// Blank lines keep the height of the font, with no width.
+			if lineMetrics, err = self.queryFontMetrics(" ", false); err != nil {
+				return metrics, err
+			}
+			lineMetrics.Width = 0
+			lineMetrics.MaxHorizontalAdvance = 0
+			lineMetrics.X1, lineMetrics.X2 = 0, 0
+		} else if lineMetrics, err = self.queryFontMetrics(line, false); err != nil {
+			return metrics, err
+		}
+
+		metrics.Lines = append(metrics.Lines, lineMetrics)
+	}
+
+	return metrics, nil
 }
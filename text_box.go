@@ -0,0 +1,352 @@
+package canvas
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Vertical position of the text within a TextBox.
+type VerticalAlignment uint
+
+const (
+	TopAlign VerticalAlignment = iota
+	MiddleAlign
+	BottomAlign
+)
+
+// What DrawText() does with text that doesn't fit in its TextBox.
+type Overflow uint
+
+const (
+	// Draws the text past the bottom of the box.
+	VisibleOverflow Overflow = iota
+	// Reduces the font size until the text fits, down to MinSize.
+	ShrinkOverflow
+	// Drops the lines that don't fit and ends the last one with an ellipsis.
+	EllipsisOverflow
+	// Clips the text at the edges of the box.
+	ClipOverflow
+)
+
+// Ellipsis appended by EllipsisOverflow.
+const ellipsis = "..."
+
+// Rectangle where DrawText() lays out text. Words are wrapped at the box
+// width, "\n" starts a new paragraph.
+type TextBox struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+	// Text properties, the canvas' current ones if nil. Their Alignment and
+	// Gravity are ignored in favor of the box's.
+	Properties *TextProperties
+	// Horizontal alignment of every line, LeftAlign if undefined.
+	Alignment Alignment
+	// Vertical alignment of the whole text.
+	VerticalAlignment VerticalAlignment
+	// Distance between baselines as a multiple of the line height, 1 if 0.
+	LineSpacing float64
+	// Overflow policy.
+	Overflow Overflow
+	// Smallest font size used by ShrinkOverflow, 1 if 0.
+	MinSize float64
+}
+
+// Lines of a text laid out in a box.
+type textLayout struct {
+	lines  []string
+	widths []float64
+	// Distance between baselines.
+	lineHeight float64
+	ascent     float64
+	descent    float64
+}
+
+// Private: returns the height of the laid out text, from the top of the
+// first line to the bottom of the last one.
+func (self *textLayout) height() float64 {
+	if len(self.lines) == 0 {
+		return 0
+	}
+	return self.lineHeight*float64(len(self.lines)-1) + self.ascent - self.descent
+}
+
+// Private: returns the width of text using the current drawing settings.
+func (self *Canvas) textWidth(text string) (float64, error) {
+	if text == "" {
+		return 0, nil
+	}
+
+	metrics, err := self.queryFontMetrics(text, false)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return metrics.Width, nil
+}
+
+// Private: splits word into chunks no wider than width, at least one rune
+// each.
+func (self *Canvas) breakWord(word string, width float64) ([]string, error) {
+	var chunks []string
+
+	runes := []rune(word)
+
+	for len(runes) > 0 {
+		n := 1
+
+		for n < len(runes) {
+			w, err := self.textWidth(string(runes[:n+1]))
+
+			if err != nil {
+				return nil, err
+			}
+
+			if w > width {
+				break
+			}
+
+			n++
+		}
+
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+
+	return chunks, nil
+}
+
+// Private: wraps text at width using the current drawing settings.
+func (self *Canvas) wrapText(text string, width float64, spacing float64) (*textLayout, error) {
+	metrics, err := self.queryFontMetrics("Mg", false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &textLayout{
+		ascent:  metrics.Ascent,
+		descent: metrics.Descent,
+	}
+
+	layout.lineHeight = (metrics.Ascent - metrics.Descent) * spacing
+
+	space, err := self.textWidth(" ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line string
+		var lineWidth float64
+
+		for _, word := range strings.Fields(paragraph) {
+			w, err := self.textWidth(word)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if line != "" && lineWidth+space+w <= width {
+				// Measuring the joined line accounts for kerning.
+				joined := line + " " + word
+
+				if lineWidth, err = self.textWidth(joined); err != nil {
+					return nil, err
+				}
+
+				line = joined
+				continue
+			}
+
+			if line != "" {
+				layout.lines = append(layout.lines, line)
+				layout.widths = append(layout.widths, lineWidth)
+			}
+
+			line, lineWidth = word, w
+
+			if w > width {
+				chunks, err := self.breakWord(word, width)
+
+				if err != nil {
+					return nil, err
+				}
+
+				for _, chunk := range chunks[:len(chunks)-1] {
+					cw, err := self.textWidth(chunk)
+
+					if err != nil {
+						return nil, err
+					}
+
+					layout.lines = append(layout.lines, chunk)
+					layout.widths = append(layout.widths, cw)
+				}
+
+				line = chunks[len(chunks)-1]
+
+				if lineWidth, err = self.textWidth(line); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		layout.lines = append(layout.lines, line)
+		layout.widths = append(layout.widths, lineWidth)
+	}
+
+	return layout, nil
+}
+
+// Private: drops the lines of layout that don't fit in height and ends the
+// last remaining line with an ellipsis no wider than width.
+func (self *Canvas) ellipsize(layout *textLayout, width float64, height float64) error {
+	if layout.height() <= height {
+		return nil
+	}
+
+	n := 1
+	if layout.lineHeight > 0 {
+		n = int(math.Floor((height-layout.ascent+layout.descent)/layout.lineHeight)) + 1
+	}
+
+	if n < 1 {
+		layout.lines, layout.widths = nil, nil
+		return nil
+	}
+
+	layout.lines = layout.lines[:n]
+	layout.widths = layout.widths[:n]
+
+	runes := []rune(strings.TrimRight(layout.lines[n-1], " "))
+
+	for {
+		line := strings.TrimRight(string(runes), " ") + ellipsis
+
+		w, err := self.textWidth(line)
+
+		if err != nil {
+			return err
+		}
+
+		if w <= width || len(runes) == 0 {
+			layout.lines[n-1], layout.widths[n-1] = line, w
+			return nil
+		}
+
+		runes = runes[:len(runes)-1]
+	}
+}
+
+// Draws text inside box on the current drawing surface, wrapping words at
+// the box width and handling overflow as set by box.Overflow. Does not modify
+// the canvas' default TextProperties.
+func (self *Canvas) DrawText(text string, box *TextBox) error {
+	if box == nil || box.Width <= 0 || box.Height <= 0 {
+		return errors.New("Could not draw text: box is empty.")
+	}
+
+	var prop TextProperties
+
+	if box.Properties != nil {
+		prop = *box.Properties
+	} else if self.text != nil {
+		prop = *self.text
+	}
+
+	// Lines are positioned by the box.
+	prop.Alignment = LeftAlign
+	prop.Gravity = UNDEFINED_GRAVITY
+
+	restore, err := self.pushTextProperties(&prop)
+
+	if err != nil {
+		return err
+	}
+
+	defer restore()
+
+	spacing := box.LineSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+
+	layout, err := self.wrapText(text, box.Width, spacing)
+
+	if err != nil {
+		return err
+	}
+
+	switch box.Overflow {
+	case ShrinkOverflow:
+		minSize := box.MinSize
+		if minSize <= 0 {
+			minSize = 1
+		}
+
+		size := self.FontSize()
+
+		for layout.height() > box.Height && size > minSize {
+			size = math.Max(minSize, size*0.9)
+			self.SetFontSize(size)
+
+			if layout, err = self.wrapText(text, box.Width, spacing); err != nil {
+				return err
+			}
+		}
+	case EllipsisOverflow:
+		if err := self.ellipsize(layout, box.Width, box.Height); err != nil {
+			return err
+		}
+	case ClipOverflow:
+		if err := self.PushDrawing(); err != nil {
+			return err
+		}
+
+		defer self.PopDrawing()
+
+		clip := NewClipPath()
+		clip.Rectangle(box.X, box.Y, box.Width, box.Height)
+
+		if err := self.setDrawingClipPath(clip); err != nil {
+			return err
+		}
+	}
+
+	var top float64
+
+	switch box.VerticalAlignment {
+	case MiddleAlign:
+		top = (box.Height - layout.height()) / 2
+	case BottomAlign:
+		top = box.Height - layout.height()
+	}
+
+	baseline := box.Y + top + layout.ascent
+
+	for i, line := range layout.lines {
+		x := box.X
+
+		switch box.Alignment {
+		case CenterAlign:
+			x += (box.Width - layout.widths[i]) / 2
+		case RightAlign:
+			x += box.Width - layout.widths[i]
+		}
+
+		if line != "" {
+			if err := self.annotate(line, x, baseline); err != nil {
+				return err
+			}
+		}
+
+		baseline += layout.lineHeight
+	}
+
+	return nil
+}